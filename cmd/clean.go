@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/knadh/koanf"
+	"github.com/spf13/cobra"
+	"github.com/state303/go-discogs/src/batch"
+	"os"
+)
+
+func newCleanCommand() *cobra.Command {
+	cleanCmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Removes old dump files from data directory",
+		Long: `clean keeps the last N generations of each types in data directory, including CHECKSUM and partially
+downloaded files, then removes the rest and reports disk usage.`,
+		Args: cobra.NoArgs,
+		RunE: getCleanFunc(),
+	}
+	f := cleanCmd.Flags()
+	f.StringP("data", "d", getDefaultDataDir(), "data file dir")
+	f.IntP("keep", "k", 2, "generations to keep for each types")
+	f.Bool("dry-run", false, "only report files to be removed")
+	return cleanCmd
+}
+
+var getCleanFunc = func() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := new(cleanValidator).Validate(conf); err != nil {
+			return err
+		}
+		return new(batch.CleanRunner).Run(conf, os.Stdout)
+	}
+}
+
+type cleanValidator struct{}
+
+// Validate command values to clean data dir
+func (v *cleanValidator) Validate(k *koanf.Koanf) error {
+	if k.Int("keep") < 1 {
+		return fmt.Errorf("keep must be at least 1")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"github.com/knadh/koanf"
+	"github.com/spf13/cobra"
+	"github.com/state303/go-discogs/src/batch"
+)
+
+func newFetchCommand() *cobra.Command {
+	fetchCmd := &cobra.Command{
+		Use:   "fetch",
+		Short: "Downloads and verifies dump files without loading them",
+		Long: `fetch downloads dump files of given year, month and types into data directory,
+then verifies their checksum. It requires no database, hence dumps can be staged ahead of a batch.`,
+		Args: cobra.NoArgs,
+		RunE: getFetchFunc(),
+	}
+	addFetchFlags(fetchCmd.Flags())
+	fetchCmd.Flags().String("metrics-addr", "", metricsAddrUsage)
+	return fetchCmd
+}
+
+var getFetchFunc = func() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := new(fetchValidator).Validate(conf); err != nil {
+			return err
+		}
+		return new(batch.FetchRunner).Run(cmd.Context(), conf)
+	}
+}
+
+func newVerifyCommand() *cobra.Command {
+	verifyCmd := &cobra.Command{
+		Use:   "verify <file|dir>...",
+		Short: "Verifies checksum of local dump files",
+		Long: `verify checks dump files, or dump files within directories, against the CHECKSUM file next to them.
+Checksum stored in data repository is used instead if the CHECKSUM file is missing and dsn is given.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: getVerifyFunc(),
+	}
+	verifyCmd.Flags().StringP("dsn", "s", "", "data source name to look up checksum. optional")
+	addNamingFlags(verifyCmd.Flags())
+	return verifyCmd
+}
+
+var getVerifyFunc = func() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if dsn := conf.String("dsn"); len(dsn) > 0 {
+			if err := ValidDsnFormat(dsn); err != nil {
+				return err
+			} else if err = ValidNaming(conf.String("schema"), conf.String("table-prefix")); err != nil {
+				return err
+			}
+		}
+		return new(batch.VerifyRunner).Run(cmd.Context(), conf, args)
+	}
+}
+
+type fetchValidator struct{}
+
+// Validate command values to fetch dump files
+func (v *fetchValidator) Validate(koanf *koanf.Koanf) error {
+	if err := ValidTargetYearMonth(koanf.String("year"), koanf.String("month")); err != nil {
+		return err
+	} else if err = ValidTypes(koanf.Strings("types")); err != nil {
+		return err
+	} else if err = ValidRetries(koanf.Int("retries")); err != nil {
+		return err
+	}
+	return ValidMaxBandwidth(koanf.String("max-bandwidth"))
+}
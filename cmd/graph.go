@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/state303/go-discogs/src/batch"
+	"regexp"
+)
+
+var DumpFilePattern = regexp.MustCompile(`discogs_\d{8}_(artists|labels|masters|releases)\.xml\.gz$`)
+
+func newGraphCommand() *cobra.Command {
+	graphCmd := &cobra.Command{
+		Use:   "graph [dump files...]",
+		Short: "Exports dump files into neo4j-admin import csv files",
+		Long: `graph exports nodes and relationships from given dump files into csv files,
+in the header format of neo4j-admin import. Types are resolved from filenames,
+such as discogs_20240301_artists.xml.gz`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: getGraphFunc(),
+	}
+	graphCmd.Flags().StringP("out", "o", "graph", "csv output dir")
+	return graphCmd
+}
+
+var getGraphFunc = func() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		typeResourceMap, err := getTypeResourceMap(args)
+		if err != nil {
+			return err
+		}
+		return new(batch.GraphRunner).Run(cmd.Context(), conf.String("out"), typeResourceMap)
+	}
+}
+
+// getTypeResourceMap maps each dump file by its types, resolved from filename.
+func getTypeResourceMap(files []string) (map[string]string, error) {
+	typeResourceMap := make(map[string]string)
+	for _, f := range files {
+		match := DumpFilePattern.FindStringSubmatch(f)
+		if match == nil {
+			return nil, fmt.Errorf("unknown dump file: %+v", f)
+		}
+		if prev, ok := typeResourceMap[match[1]]; ok {
+			return nil, fmt.Errorf("duplicate %+v dump files: %+v, %+v", match[1], prev, f)
+		}
+		typeResourceMap[match[1]] = f
+	}
+	return typeResourceMap, nil
+}
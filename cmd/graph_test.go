@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_getTypeResourceMap(t *testing.T) {
+	t.Run("types resolved from filename", func(t *testing.T) {
+		m, err := getTypeResourceMap([]string{"dumps/discogs_20240301_artists.xml.gz", "discogs_20240301_releases.xml.gz"})
+		require.NoError(t, err)
+		require.Equal(t, "dumps/discogs_20240301_artists.xml.gz", m["artists"])
+		require.Equal(t, "discogs_20240301_releases.xml.gz", m["releases"])
+	})
+	t.Run("unknown file returns error", func(t *testing.T) {
+		_, err := getTypeResourceMap([]string{"artists.csv"})
+		require.ErrorContains(t, err, "unknown dump file")
+	})
+	t.Run("duplicate types returns error", func(t *testing.T) {
+		_, err := getTypeResourceMap([]string{"discogs_20240301_labels.xml.gz", "discogs_20240201_labels.xml.gz"})
+		require.ErrorContains(t, err, "duplicate")
+	})
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/knadh/koanf"
+	"github.com/spf13/cobra"
+	"github.com/state303/go-discogs/src/batch"
+	"os"
+)
+
+func newListCommand() *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists dump catalogue from data repository",
+		Long: `list prints dump data stored by --update, with generation date, types, size and checksum,
+along with whether each dump is downloaded in data directory and loaded into the database.`,
+		Args: cobra.NoArgs,
+		RunE: getListFunc(),
+	}
+	f := listCmd.Flags()
+	f.StringP("dsn", "s", "", "data source name. expects format of (postgres|mysql)://root:pass@localhost:5432/dbname")
+	f.StringP("data", "d", getDefaultDataDir(), "data file dir")
+	f.StringP("year", "y", "", "filter by year of generation")
+	f.StringSliceP("types", "t", []string{"artists", "labels", "masters", "releases"}, "filter by types")
+	f.BoolP("latest", "l", false, "only the latest generation of each types")
+	f.StringP("output", "o", "table", "output format. either table or json")
+	addNamingFlags(f)
+	return listCmd
+}
+
+var getListFunc = func() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := new(listValidator).Validate(conf); err != nil {
+			return err
+		}
+		return new(batch.ListRunner).Run(conf, os.Stdout)
+	}
+}
+
+type listValidator struct{}
+
+// Validate command values to list dump catalogue
+func (v *listValidator) Validate(k *koanf.Koanf) error {
+	if err := ValidYearMonth(k.String("year"), ""); err != nil {
+		return err
+	} else if err = ValidTypes(k.Strings("types")); err != nil {
+		return err
+	} else if err = ValidDsnFormat(k.String("dsn")); err != nil {
+		return err
+	} else if err = ValidNaming(k.String("schema"), k.String("table-prefix")); err != nil {
+		return err
+	} else if o := k.String("output"); o != "table" && o != "json" {
+		return fmt.Errorf("unknown output format: %+v", o)
+	}
+	return nil
+}
@@ -11,10 +11,16 @@ import (
 	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/providers/posflag"
 	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/state303/go-discogs/src/batch"
+	"github.com/state303/go-discogs/src/decompress"
+	"github.com/state303/go-discogs/src/logging"
+	"github.com/state303/go-discogs/src/pipeline"
+	"github.com/state303/go-discogs/src/tracing"
 	"os"
+	"runtime"
 	"strings"
 	"time"
 )
@@ -24,15 +30,22 @@ const Prefix = "GO_DISCOGS_"
 const versionPrintPrefix = "go-discogs"
 
 var version string
+
+const metricsAddrUsage = "address to serve prometheus metrics at /metrics during the run, such as :9090. disabled if empty"
+
 var conf = koanf.New(".")
 var sep = string(os.PathSeparator)
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// Execution is cancelled on SIGINT or SIGTERM, then exits with ExitInterrupted.
 func Execute() {
-	err := NewRootCommand().Execute()
+	ctx, stop := newSignalContext(context.Background())
+	err := NewRootCommand().ExecuteContext(ctx)
+	stop()
 	if err != nil {
-		fmt.Printf("critical error: %+v\n", err.Error())
+		logrus.WithError(err).Error("critical error")
+		os.Exit(getExitCode(err))
 	}
 }
 
@@ -43,28 +56,75 @@ func NewRootCommand() *cobra.Command {
 		Long: `go-discogs is a data dump batch application written in Go.
 Currently supports databases: PostgresQL, MySQL.`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			return load(cmd.Flags(), conf)
+			format, _ := cmd.Flags().GetString("log-format")
+			if err := logging.Configure(format, os.Stdout); err != nil {
+				return err
+			}
+			if err := load(cmd.Flags(), conf); err != nil {
+				return err
+			}
+			return logging.Configure(conf.String("log-format"), os.Stdout) // may be set by config file or env
 		},
 		RunE: getMainFunc(),
 	}
+	home := getDefaultDataDir()
+	rootCmd.PersistentFlags().StringP("config", "c", home+sep+"config.yaml", "config file path")
+	rootCmd.PersistentFlags().String("log-format", logging.FormatText, "log format. either text or json. progress bars are shown only for text on a terminal")
 	f := rootCmd.Flags()
-	y, m := time.Now().Format("2006"), time.Now().Format("01")
-	home := getHomeDir(new(homeDirSupplier))
-	home += sep + "go-discogs"
 	f.BoolP("new", "n", false, "generates tables before batch")
-	f.StringP("config", "c", home+sep+"config.yaml", "config file path")
-	f.StringP("data", "d", home, "data file dir")
-	f.StringSliceP("types", "t", []string{"artists", "labels", "masters", "releases"}, "target types")
-	f.StringP("year", "y", y, "target year")
-	f.StringP("month", "m", m, "target month")
+	addFetchFlags(f)
 	f.BoolP("version", "v", false, "prints version")
 	f.IntP("chunk", "b", 5000, "chunk size")
+	f.Int("workers", runtime.NumCPU(), "goroutines decoding elements and preparing chunks of rows. defaults to number of cpus if zero")
+	f.Int("writers", pipeline.DefaultWriters, "chunks written concurrently, also the bound of chunks waiting for writers")
+	f.String("gzip", decompress.GzipStandard, "gzip implementation. either standard, or parallel for klauspost/pgzip. zstd and plain xml are detected")
 	f.BoolP("update", "u", false, "update data repo")
 	f.BoolP("purge", "p", false, "purge files after success")
+	f.Bool("fast-load", false, "with --new or --atomic, creates tables only, then builds indexes and foreign keys after loading")
+	f.Bool("atomic", false, "load into a shadow schema, then swap it in once every types succeed. requires every types")
+	f.String("on-error", "fail", "error policy for malformed elements and rejected rows. either fail, skip or deadletter")
+	f.String("dead-letter", "", "dead-letter file path, or table for dead_letter table. defaults to deadletter.jsonl in data dir")
+	f.Duration("lock-timeout", 0, "wait up to this duration, such as 10m, for another run on the same schema. fails fast if zero")
+	f.Int("keep", 0, "generations of dump files to keep for each types after success. keeps all if zero")
 	f.StringP("dsn", "s", "", "data source name. expects format of (postgres|mysql)://root:pass@localhost:5432/dbname")
+	addNamingFlags(f)
+	f.String("metrics-addr", "", metricsAddrUsage)
+	f.String("trace-exporter", tracing.ExporterNone, "opentelemetry span exporter. either none, otlp, stderr or file")
+	f.String("trace-endpoint", "", "otlp collector url, such as http://localhost:4318. defaults to OTEL_EXPORTER_OTLP_ENDPOINT")
+	f.String("trace-file", "", "file to append spans to as json, for trace-exporter of file")
+	rootCmd.AddCommand(newGraphCommand())
+	rootCmd.AddCommand(newFetchCommand())
+	rootCmd.AddCommand(newVerifyCommand())
+	rootCmd.AddCommand(newListCommand())
+	rootCmd.AddCommand(newCleanCommand())
+	rootCmd.AddCommand(newStatusCommand())
 	return rootCmd
 }
 
+// addFetchFlags adds flags to select and download dump files.
+func addFetchFlags(f *pflag.FlagSet) {
+	y, m := time.Now().Format("2006"), time.Now().Format("01")
+	f.StringP("data", "d", getDefaultDataDir(), "data file dir")
+	f.StringSliceP("types", "t", []string{"artists", "labels", "masters", "releases"}, "target types")
+	f.StringP("year", "y", y, "target year, or latest for the newest complete generation")
+	f.StringP("month", "m", m, "target month")
+	f.Bool("latest", false, "use the newest generation having every target types")
+	f.Bool("fallback", false, "walk back month by month until a generation having every target types is found")
+	f.String("source", "s3", "dump source. either s3, mirror base url (http(s)://...) or local directory")
+	f.Int("retries", 3, "download retries with exponential backoff")
+	f.String("max-bandwidth", "", "download bandwidth limit per second, such as 512K or 10M. unlimited if empty")
+}
+
+// addNamingFlags adds flags to place tables into a schema with a prefix.
+func addNamingFlags(f *pflag.FlagSet) {
+	f.String("schema", "", "schema to create and load tables into. defaults to search_path of dsn")
+	f.String("table-prefix", "", "prefix of every table, index and constraint name, such as discogs_")
+}
+
+func getDefaultDataDir() string {
+	return getHomeDir(new(homeDirSupplier)) + sep + "go-discogs"
+}
+
 var getMainFunc = func() func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		if ok, _ := cmd.Flags().GetBool("version"); ok {
@@ -74,7 +134,7 @@ var getMainFunc = func() func(cmd *cobra.Command, args []string) error {
 		if err := new(validator).Validate(conf); err != nil {
 			return err
 		}
-		return new(batch.Runner).Run(context.Background(), conf)
+		return (&batch.Runner{Version: version}).Run(cmd.Context(), conf)
 	}
 }
 
@@ -141,9 +201,9 @@ func getConfigFilePath(flags *pflag.FlagSet) string {
 
 func loadConfigFile(k *koanf.Koanf, path string) {
 	if err := k.Load(file.Provider(path), getParser(path)); err == nil {
-		fmt.Printf("located config [%+v]: loaded\n", path)
+		logrus.WithField("config", path).Info("located config: loaded")
 	} else {
-		fmt.Printf("failed to locate config [%+v]: skipping\n", path)
+		logrus.WithField("config", path).Info("failed to locate config: skipping")
 	}
 }
 
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const (
+	ExitFailure = 1
+	// ExitInterrupted follows the shell convention of 128 + SIGINT.
+	ExitInterrupted = 130
+)
+
+// newSignalContext returns context cancelled on SIGINT or SIGTERM, so that in-flight chunks are committed
+// before the batch stops. Another signal exits immediately.
+func newSignalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	sig := make(chan os.Signal, 2)
+	stopped := make(chan struct{})
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case s := <-sig:
+			logrus.WithField("signal", s.String()).Warn("finishing in-flight chunks. send again to quit immediately")
+			cancel()
+		case <-stopped:
+			return
+		}
+		select {
+		case <-sig:
+			os.Exit(ExitInterrupted)
+		case <-stopped:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sig)
+		close(stopped)
+		cancel()
+	}
+}
+
+// getExitCode returns ExitInterrupted for errors caused by cancellation, otherwise ExitFailure.
+func getExitCode(err error) int {
+	if errors.Is(err, context.Canceled) {
+		return ExitInterrupted
+	}
+	return ExitFailure
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestGetExitCode(t *testing.T) {
+	require.Equal(t, ExitInterrupted, getExitCode(fmt.Errorf("step failed: %w", context.Canceled)))
+	require.Equal(t, ExitFailure, getExitCode(errors.New("failed")))
+}
+
+func TestNewSignalContext(t *testing.T) {
+	t.Run("cancelled on signal", func(t *testing.T) {
+		ctx, stop := newSignalContext(context.Background())
+		defer stop()
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("context is not cancelled on signal")
+		}
+	})
+	t.Run("cancelled on stop", func(t *testing.T) {
+		ctx, stop := newSignalContext(context.Background())
+		stop()
+		require.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+}
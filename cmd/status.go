@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/knadh/koanf"
+	"github.com/spf13/cobra"
+	"github.com/state303/go-discogs/src/batch"
+	"os"
+)
+
+func newStatusCommand() *cobra.Command {
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Shows freshness of each types and the last batch runs",
+		Long: `status prints the generation each types currently reflects, which is the dump loaded last,
+followed by the last batch runs with their outcome, counts and tool version.`,
+		Args: cobra.NoArgs,
+		RunE: getStatusFunc(),
+	}
+	f := statusCmd.Flags()
+	f.StringP("dsn", "s", "", "data source name. expects format of (postgres|mysql)://root:pass@localhost:5432/dbname")
+	f.StringSliceP("types", "t", []string{"artists", "labels", "masters", "releases"}, "filter freshness by types")
+	f.IntP("last", "l", 10, "number of runs to show")
+	f.StringP("output", "o", "table", "output format. either table or json")
+	addNamingFlags(f)
+	return statusCmd
+}
+
+var getStatusFunc = func() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := new(statusValidator).Validate(conf); err != nil {
+			return err
+		}
+		return new(batch.StatusRunner).Run(conf, os.Stdout)
+	}
+}
+
+type statusValidator struct{}
+
+// Validate command values to show status
+func (v *statusValidator) Validate(k *koanf.Koanf) error {
+	if err := ValidTypes(k.Strings("types")); err != nil {
+		return err
+	} else if err = ValidDsnFormat(k.String("dsn")); err != nil {
+		return err
+	} else if err = ValidNaming(k.String("schema"), k.String("table-prefix")); err != nil {
+		return err
+	} else if k.Int("last") < 1 {
+		return fmt.Errorf("last must be at least 1")
+	} else if o := k.String("output"); o != "table" && o != "json" {
+		return fmt.Errorf("unknown output format: %+v", o)
+	}
+	return nil
+}
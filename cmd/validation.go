@@ -4,6 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"github.com/knadh/koanf"
+	"github.com/state303/go-discogs/src/batch"
+	"github.com/state303/go-discogs/src/decompress"
+	"github.com/state303/go-discogs/src/helper"
+	"github.com/state303/go-discogs/src/tracing"
 	"regexp"
 	"strconv"
 	"strings"
@@ -12,6 +16,7 @@ import (
 var YearPattern = regexp.MustCompile(`^\d{4}$`)
 var MonthPattern = regexp.MustCompile(`^(0?[1-9]|1[0-2])$`)
 var PluralPattern = regexp.MustCompile(`^.*s$`)
+var IdentifierPattern = regexp.MustCompile(`^[A-Za-z_]\w*$`)
 var DslPattern = regexp.MustCompile(`^(mysql|postgres)://([^/]+:[^/]+)@([^/]+:\d+)(/.*)?$`)
 
 type ConfigValidator interface {
@@ -23,16 +28,85 @@ type validator struct{}
 // Validate command values
 func (v *validator) Validate(koanf *koanf.Koanf) error {
 	y, m := koanf.String("year"), koanf.String("month")
-	if err := ValidYearMonth(y, m); err != nil {
+	if err := ValidTargetYearMonth(y, m); err != nil {
 		return err
 	} else if err = ValidTypes(koanf.Strings("types")); err != nil {
 		return err
 	} else if err = ValidDsnFormat(koanf.String("dsn")); err != nil {
 		return err
+	} else if err = ValidNaming(koanf.String("schema"), koanf.String("table-prefix")); err != nil {
+		return err
+	} else if err = ValidRetries(koanf.Int("retries")); err != nil {
+		return err
+	} else if err = ValidMaxBandwidth(koanf.String("max-bandwidth")); err != nil {
+		return err
+	} else if koanf.Int("workers") < 0 {
+		return fmt.Errorf("workers cannot be negative")
+	} else if koanf.Int("writers") < 0 {
+		return fmt.Errorf("writers cannot be negative")
+	} else if koanf.Int("keep") < 0 {
+		return fmt.Errorf("keep cannot be negative")
+	} else if koanf.Duration("lock-timeout") < 0 {
+		return fmt.Errorf("lock-timeout cannot be negative")
+	} else if _, err = batch.ParseErrorPolicy(koanf.String("on-error")); err != nil {
+		return err
+	} else if err = decompress.ValidGzip(koanf.String("gzip")); err != nil {
+		return err
+	} else if err = ValidTracing(koanf.String("trace-exporter"), koanf.String("trace-file")); err != nil {
+		return err
+	} else if err = ValidAtomic(koanf.Bool("atomic"), koanf.Strings("types")); err != nil {
+		return err
+	} else if koanf.Bool("fast-load") && !koanf.Bool("new") && !koanf.Bool("atomic") {
+		return fmt.Errorf("fast-load requires new or atomic, as tables must be created without constraints")
 	}
 	return ValidChunkSize(koanf.String("chunk"))
 }
 
+// ValidAtomic requires every types on atomic mode, as the swap replaces tables of every types.
+func ValidAtomic(atomic bool, types []string) error {
+	if !atomic {
+		return nil
+	}
+	if len(types) == 0 || len(getTypes(types)) < len(Types) {
+		return fmt.Errorf("atomic mode requires every types: %+v", strings.Join(types, ","))
+	}
+	return nil
+}
+
+// ValidTracing accepts known span exporters only. File exporter requires file to write into.
+func ValidTracing(exporter, file string) error {
+	if err := tracing.ValidExporter(exporter); err != nil {
+		return err
+	} else if exporter == tracing.ExporterFile && len(file) == 0 {
+		return fmt.Errorf("trace-file is required for trace-exporter of file")
+	}
+	return nil
+}
+
+// ValidNaming accepts schema and table prefix of letters, digits and underscores only, as they are part of raw statements.
+func ValidNaming(schema, tablePrefix string) error {
+	if len(schema) > 0 && !IdentifierPattern.MatchString(schema) {
+		return fmt.Errorf("invalid schema: %+v", schema)
+	} else if len(tablePrefix) > 0 && !IdentifierPattern.MatchString(tablePrefix) {
+		return fmt.Errorf("invalid table-prefix: %+v", tablePrefix)
+	}
+	return nil
+}
+
+func ValidRetries(retries int) (err error) {
+	if retries < 0 {
+		err = fmt.Errorf("retries cannot be negative")
+	}
+	return
+}
+
+func ValidMaxBandwidth(maxBandwidth string) (err error) {
+	if _, err = helper.ParseByteSize(maxBandwidth); err != nil {
+		err = fmt.Errorf("invalid max-bandwidth option: %w", err)
+	}
+	return
+}
+
 func ValidChunkSize(chunkSizeVal string) (err error) {
 	if ok, _ := regexp.MatchString("^\\d+$", chunkSizeVal); !ok {
 		err = fmt.Errorf("invalid chunk option: %+v", chunkSizeVal)
@@ -68,6 +142,14 @@ func ValidTypes(types []string) (err error) {
 	return
 }
 
+// ValidTargetYearMonth validates year and month of dumps to load, where year may be latest.
+func ValidTargetYearMonth(y, m string) error {
+	if y == "latest" {
+		y = ""
+	}
+	return ValidYearMonth(y, m)
+}
+
 // ValidYearMonth validates year and month if it has given user command value.
 func ValidYearMonth(y, m string) (err error) {
 	if len(y) > 0 && !YearPattern.MatchString(y) { // invalid year set
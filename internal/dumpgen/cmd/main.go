@@ -0,0 +1,64 @@
+// Command dumpgen writes a synthetic dump to measure ingest with, such as:
+//
+//	go run ./internal/dumpgen/cmd -type releases -count 100000 -o discogs_20240101_releases.xml.gz
+//
+// Output is gzip compressed if its name ends with .gz, and written to stdout if no name is given.
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"github.com/state303/go-discogs/internal/dumpgen"
+	"os"
+	"strings"
+)
+
+func main() {
+	var (
+		opts dumpgen.Options
+		typ  = flag.String("type", dumpgen.TypeReleases, "dump type. either artists, labels, masters or releases")
+		out  = flag.String("o", "", "output file. gzip compressed if ends with .gz. defaults to stdout")
+	)
+	flag.Int64Var(&opts.Seed, "seed", 0, "random seed. same seed and options write the same dump")
+	flag.IntVar(&opts.Count, "count", 1000, "elements to write")
+	flag.IntVar(&opts.Refs, "refs", 0, "artists, labels and masters referred to. defaults to count")
+	flag.IntVar(&opts.Tracks, "tracks", 10, "tracks per release")
+	flag.IntVar(&opts.Credits, "credits", 2, "artists credited per master, release and track")
+	flag.BoolVar(&opts.Unicode, "unicode", false, "write names and titles in scripts other than latin as well")
+	flag.Float64Var(&opts.MalformedDates, "malformed-dates", 0, "ratio of releases with malformed released dates, from 0 to 1")
+	flag.Parse()
+
+	if err := run(*typ, *out, opts); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(typ, out string, opts dumpgen.Options) error {
+	if err := dumpgen.ValidType(typ); err != nil {
+		return err
+	}
+	if len(out) == 0 {
+		return dumpgen.Write(os.Stdout, typ, opts)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if !strings.HasSuffix(out, ".gz") {
+		if err = dumpgen.Write(f, typ, opts); err != nil {
+			return err
+		}
+		return f.Close()
+	}
+	w := gzip.NewWriter(f)
+	if err = dumpgen.Write(w, typ, opts); err != nil {
+		return err
+	} else if err = w.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,364 @@
+// Package dumpgen writes synthetic dumps of artists, labels, masters and releases, shaped as those of discogs,
+// so that ingest can be measured at any size without downloading dumps. Output is reproducible by Seed.
+// Elements refer to each other by ids from 1 to Refs, so that dumps of the same Options link together.
+package dumpgen
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"github.com/state303/go-discogs/pkg/discogs"
+	"io"
+	"math/rand"
+	"strings"
+)
+
+// Types of dumps to write.
+const (
+	TypeArtists  = "artists"
+	TypeLabels   = "labels"
+	TypeMasters  = "masters"
+	TypeReleases = "releases"
+)
+
+// Options configures size and shape of dumps. Zero values are replaced by defaults.
+type Options struct {
+	// Seed makes output reproducible. Same Seed and Options write the same dump.
+	Seed int64
+	// Count is the number of elements to write. Defaults to 1000.
+	Count int
+	// Refs is the number of artists, labels and masters referred to, such as credits and aliases. Defaults to Count.
+	Refs int
+	// Tracks is the number of tracks per release. Defaults to 10.
+	Tracks int
+	// Credits is the number of artists credited per master, release and track, also of extra artists of
+	// releases. Defaults to 2.
+	Credits int
+	// Unicode writes names and titles in scripts other than latin as well, such as hangul and cyrillic.
+	Unicode bool
+	// MalformedDates is the ratio of releases from 0 to 1 of which released date cannot be parsed, such as 19??.
+	MalformedDates float64
+}
+
+func (o Options) withDefaults() Options {
+	if o.Count < 1 {
+		o.Count = 1000
+	}
+	if o.Refs < 1 {
+		o.Refs = o.Count
+	}
+	if o.Tracks < 1 {
+		o.Tracks = 10
+	}
+	if o.Credits < 1 {
+		o.Credits = 2
+	}
+	return o
+}
+
+// ValidType returns error if typ is none of known types.
+func ValidType(typ string) error {
+	switch typ {
+	case TypeArtists, TypeLabels, TypeMasters, TypeReleases:
+		return nil
+	}
+	return fmt.Errorf("unknown dump type: %+v", typ)
+}
+
+// Write writes dump of typ into w.
+func Write(w io.Writer, typ string, opts Options) error {
+	switch typ {
+	case TypeArtists:
+		return WriteArtists(w, opts)
+	case TypeLabels:
+		return WriteLabels(w, opts)
+	case TypeMasters:
+		return WriteMasters(w, opts)
+	case TypeReleases:
+		return WriteReleases(w, opts)
+	}
+	return ValidType(typ)
+}
+
+// WriteArtists writes artists dump into w.
+func WriteArtists(w io.Writer, opts Options) error {
+	return write(w, "artist", opts, func(g *generator, id int32) interface{} {
+		return g.artist(id)
+	})
+}
+
+// WriteLabels writes labels dump into w. Labels have sub labels, which are label elements nested in label elements.
+func WriteLabels(w io.Writer, opts Options) error {
+	return write(w, "label", opts, func(g *generator, id int32) interface{} {
+		return g.label(id)
+	})
+}
+
+// WriteMasters writes masters dump into w.
+func WriteMasters(w io.Writer, opts Options) error {
+	return write(w, "master", opts, func(g *generator, id int32) interface{} {
+		return g.master(id)
+	})
+}
+
+// WriteReleases writes releases dump into w.
+func WriteReleases(w io.Writer, opts Options) error {
+	return write(w, "release", opts, func(g *generator, id int32) interface{} {
+		return g.release(id)
+	})
+}
+
+// write writes Count elements of localName made by newElement into w, one per line within the root element.
+func write(w io.Writer, localName string, opts Options, newElement func(g *generator, id int32) interface{}) error {
+	opts = opts.withDefaults()
+	g := &generator{rand.New(rand.NewSource(opts.Seed)), opts}
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString("<" + localName + "s>\n"); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(bw)
+	start := xml.StartElement{Name: xml.Name{Local: localName}}
+	for id := int32(1); id <= int32(opts.Count); id++ {
+		if err := encoder.EncodeElement(newElement(g, id), start); err != nil {
+			return fmt.Errorf("failed to write %+v %+v: %w", localName, id, err)
+		}
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	if _, err := bw.WriteString("</" + localName + "s>\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+var (
+	latinWords = []string{"Planet", "Echo", "Night", "Sound", "System", "Deep", "Blue", "Tone", "Machine", "Garden",
+		"Future", "Soul", "Wave", "Motion", "Static", "Orbit", "Velvet", "Signal", "Dust", "Harbor"}
+	unicodeWords = []string{"Björk", "Sigur Rós", "Мумий Тролль", "서태지", "坂本龍一", "ゆらゆら帝国", "Ελλάδα", "Kraftwërk",
+		"Ça Va", "Ñandú", "שלום", "東京", "Dvořák", "Łódź"}
+	genres = []string{"Electronic", "Rock", "Jazz", "Hip Hop", "Funk / Soul", "Pop", "Classical", "Latin", "Reggae",
+		"Folk, World, & Country", "Blues", "Stage & Screen", "Non-Music", "Children's", "Brass & Military"}
+	styles = []string{"Techno", "House", "Deep House", "Ambient", "Minimal", "Drum n Bass", "Dub", "Experimental",
+		"Indie Rock", "Punk", "Hard Bop", "Soul-Jazz", "Boom Bap", "Disco", "Synth-pop", "Downtempo", "Trance"}
+	qualities  = []string{"Correct", "Complete and Correct", "Needs Vote", "Needs Minor Changes", "Needs Major Changes"}
+	countries  = []string{"US", "UK", "Germany", "Japan", "France", "Sweden", "Netherlands", "South Korea", "Brazil"}
+	formats    = []string{"Vinyl", "CD", "Cassette", "File", "CDr", "Box Set"}
+	formatDesc = []string{"LP", "12\"", "33 ⅓ RPM", "Album", "EP", "Single", "Compilation", "Reissue", "Stereo"}
+	roles      = []string{"Producer", "Mastered By", "Written-By", "Mixed By", "Artwork", "Photography By",
+		"Remix", "Engineer", "Lacquer Cut By"}
+	identifierTypes = []string{"Barcode", "Matrix / Runout", "Label Code", "Rights Society", "Other"}
+	entityTypes     = []string{"Pressed By", "Distributed By", "Recorded At", "Phonographic Copyright (p)"}
+	malformedDates  = []string{"19??", "2001-13-45", "Unknown", "198", "2000-02-30", "?", "20O5"}
+)
+
+// generator makes elements from its random source.
+type generator struct {
+	rand *rand.Rand
+	opts Options
+}
+
+func (g *generator) pick(values []string) string {
+	return values[g.rand.Intn(len(values))]
+}
+
+// words returns n words, some of which are in scripts other than latin if Unicode.
+func (g *generator) words(n int) string {
+	w := make([]string, n)
+	for i := range w {
+		if g.opts.Unicode && g.rand.Intn(3) == 0 {
+			w[i] = g.pick(unicodeWords)
+		} else {
+			w[i] = g.pick(latinWords)
+		}
+	}
+	return strings.Join(w, " ")
+}
+
+func (g *generator) ref() int32 {
+	return int32(g.rand.Intn(g.opts.Refs) + 1)
+}
+
+func (g *generator) refs(n int) []discogs.Ref {
+	refs := make([]discogs.Ref, n)
+	for i := range refs {
+		refs[i] = discogs.Ref{ID: g.ref(), Name: g.words(2)}
+	}
+	return refs
+}
+
+func (g *generator) urls(n int) []string {
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://www.example.com/%d/%s", g.rand.Intn(1<<20), strings.ToLower(g.pick(latinWords)))
+	}
+	return urls
+}
+
+func (g *generator) images(n int) []discogs.Image {
+	images := make([]discogs.Image, n)
+	for i := range images {
+		images[i] = discogs.Image{Type: "secondary", Width: 600, Height: 600}
+	}
+	if n > 0 {
+		images[0].Type = "primary"
+	}
+	return images
+}
+
+func (g *generator) credits(n int, withRoles bool) []discogs.ArtistCredit {
+	credits := make([]discogs.ArtistCredit, n)
+	for i := range credits {
+		credits[i] = discogs.ArtistCredit{ID: g.ref(), Name: g.words(2)}
+		if i < n-1 {
+			credits[i].Join = "&"
+		}
+		if withRoles {
+			credits[i].Role = g.pick(roles)
+		}
+	}
+	return credits
+}
+
+func (g *generator) videos(n int) []discogs.Video {
+	videos := make([]discogs.Video, n)
+	for i := range videos {
+		videos[i] = discogs.Video{
+			URL:         fmt.Sprintf("https://www.youtube.com/watch?v=%011x", g.rand.Int63()),
+			Duration:    g.rand.Intn(600) + 60,
+			Embed:       true,
+			Title:       g.words(3),
+			Description: g.words(6),
+		}
+	}
+	return videos
+}
+
+func (g *generator) pickN(values []string, n int) []string {
+	picked := make([]string, n)
+	for i := range picked {
+		picked[i] = g.pick(values)
+	}
+	return picked
+}
+
+// released returns released date of various precisions, or a malformed one at the ratio of MalformedDates.
+func (g *generator) released() string {
+	if g.rand.Float64() < g.opts.MalformedDates {
+		return g.pick(malformedDates)
+	}
+	y := 1950 + g.rand.Intn(75)
+	switch g.rand.Intn(4) {
+	case 0:
+		return fmt.Sprint(y)
+	case 1:
+		return fmt.Sprintf("%d-00-00", y)
+	case 2:
+		return fmt.Sprintf("%d-%02d-00", y, g.rand.Intn(12)+1)
+	}
+	return fmt.Sprintf("%d-%02d-%02d", y, g.rand.Intn(12)+1, g.rand.Intn(28)+1)
+}
+
+func (g *generator) artist(id int32) *discogs.Artist {
+	return &discogs.Artist{
+		ID:             id,
+		Name:           g.words(2),
+		RealName:       g.words(2),
+		Profile:        g.words(20),
+		DataQuality:    g.pick(qualities),
+		Images:         g.images(g.rand.Intn(3)),
+		URLs:           g.urls(g.rand.Intn(4)),
+		NameVariations: g.pickN(latinWords, g.rand.Intn(4)),
+		Aliases:        g.refs(g.rand.Intn(3)),
+		Members:        g.refs(g.rand.Intn(3)),
+		Groups:         g.refs(g.rand.Intn(2)),
+	}
+}
+
+func (g *generator) label(id int32) *discogs.Label {
+	l := &discogs.Label{
+		ID:          id,
+		Name:        g.words(2),
+		ContactInfo: g.words(8),
+		Profile:     g.words(20),
+		DataQuality: g.pick(qualities),
+		Images:      g.images(g.rand.Intn(3)),
+		URLs:        g.urls(g.rand.Intn(3)),
+		SubLabels:   g.refs(g.rand.Intn(4)),
+	}
+	if g.rand.Intn(4) == 0 {
+		l.ParentLabel = &discogs.Ref{ID: g.ref(), Name: g.words(2)}
+	}
+	return l
+}
+
+func (g *generator) master(id int32) *discogs.Master {
+	return &discogs.Master{
+		ID:          id,
+		MainRelease: g.ref(),
+		Title:       g.words(3),
+		Year:        int16(1950 + g.rand.Intn(75)),
+		DataQuality: g.pick(qualities),
+		Images:      g.images(g.rand.Intn(3)),
+		Artists:     g.credits(g.opts.Credits, false),
+		Genres:      g.pickN(genres, g.rand.Intn(2)+1),
+		Styles:      g.pickN(styles, g.rand.Intn(3)+1),
+		Videos:      g.videos(g.rand.Intn(3)),
+	}
+}
+
+func (g *generator) release(id int32) *discogs.Release {
+	r := &discogs.Release{
+		ID:           id,
+		Status:       "Accepted",
+		Title:        g.words(3),
+		Country:      g.pick(countries),
+		Released:     g.released(),
+		Notes:        g.words(g.rand.Intn(30)),
+		DataQuality:  g.pick(qualities),
+		Images:       g.images(g.rand.Intn(4)),
+		Artists:      g.credits(g.opts.Credits, false),
+		ExtraArtists: g.credits(g.opts.Credits, true),
+		Genres:       g.pickN(genres, g.rand.Intn(2)+1),
+		Styles:       g.pickN(styles, g.rand.Intn(3)+1),
+		Videos:       g.videos(g.rand.Intn(3)),
+	}
+	if g.rand.Intn(3) > 0 {
+		r.Master = discogs.ReleaseMaster{ID: g.ref(), IsMainRelease: g.rand.Intn(2) == 0}
+	}
+	for i := g.rand.Intn(2) + 1; i > 0; i-- {
+		r.Labels = append(r.Labels, discogs.ReleaseLabel{ID: g.ref(), Name: g.words(2), CatNo: fmt.Sprintf("CAT%03d", g.rand.Intn(1000))})
+	}
+	r.Formats = []discogs.Format{{
+		Name:         g.pick(formats),
+		Quantity:     fmt.Sprint(g.rand.Intn(2) + 1),
+		Descriptions: g.pickN(formatDesc, g.rand.Intn(3)+1),
+	}}
+	for i := 1; i <= g.opts.Tracks; i++ {
+		t := discogs.Track{
+			Position: fmt.Sprintf("%c%d", 'A'+rune((i-1)/4), (i-1)%4+1),
+			Title:    g.words(3),
+			Duration: fmt.Sprintf("%d:%02d", g.rand.Intn(10)+1, g.rand.Intn(60)),
+		}
+		if g.rand.Intn(4) == 0 {
+			t.ExtraArtists = g.credits(1, true)
+		}
+		r.Tracks = append(r.Tracks, t)
+	}
+	for i := g.rand.Intn(4); i > 0; i-- {
+		r.Identifiers = append(r.Identifiers, discogs.Identifier{Type: g.pick(identifierTypes), Value: fmt.Sprint(g.rand.Int63())})
+	}
+	for i := g.rand.Intn(3); i > 0; i-- {
+		r.Companies = append(r.Companies, discogs.Company{
+			ID:             g.ref(),
+			Name:           g.words(2),
+			EntityType:     int32(g.rand.Intn(30) + 1),
+			EntityTypeName: g.pick(entityTypes),
+			ResourceURL:    fmt.Sprintf("https://api.discogs.com/labels/%d", g.ref()),
+		})
+	}
+	return r
+}
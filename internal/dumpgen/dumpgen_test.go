@@ -0,0 +1,88 @@
+package dumpgen
+
+import (
+	"bytes"
+	"context"
+	"github.com/state303/go-discogs/pkg/discogs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	opts := Options{Seed: 7, Count: 50, Refs: 20, Tracks: 4, Credits: 3, Unicode: true}
+	for _, typ := range []string{TypeArtists, TypeLabels, TypeMasters, TypeReleases} {
+		t.Run(typ, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			require.NoError(t, Write(buf, typ, opts))
+			ctx := context.Background()
+			count := 0
+			var err error
+			switch typ {
+			case TypeArtists:
+				count, err = countAll(discogs.NewArtists(ctx, buf))
+			case TypeLabels:
+				count, err = countAll(discogs.NewLabels(ctx, buf))
+			case TypeMasters:
+				count, err = countAll(discogs.NewMasters(ctx, buf))
+			case TypeReleases:
+				count, err = countAll(discogs.NewReleases(ctx, buf))
+			}
+			require.NoError(t, err)
+			require.Equal(t, 50, count)
+		})
+	}
+	t.Run("rejects unknown type", func(t *testing.T) {
+		require.ErrorContains(t, Write(new(bytes.Buffer), "tracks", opts), "unknown dump type")
+	})
+}
+
+func countAll[T any](it *discogs.Iterator[T]) (int, error) {
+	count := 0
+	for it.Next() {
+		count++
+	}
+	return count, it.Err()
+}
+
+func TestWriteReleases(t *testing.T) {
+	write := func(opts Options) []*discogs.Release {
+		buf := new(bytes.Buffer)
+		require.NoError(t, WriteReleases(buf, opts))
+		it := discogs.NewReleases(context.Background(), buf)
+		releases := make([]*discogs.Release, 0)
+		for it.Next() {
+			releases = append(releases, it.Value())
+		}
+		require.NoError(t, it.Err())
+		return releases
+	}
+
+	t.Run("is reproducible by seed", func(t *testing.T) {
+		opts := Options{Seed: 42, Count: 10}
+		require.Equal(t, write(opts), write(opts))
+		opts.Seed = 43
+		require.NotEqual(t, write(Options{Seed: 42, Count: 10}), write(opts))
+	})
+	t.Run("shapes releases by options", func(t *testing.T) {
+		releases := write(Options{Count: 5, Refs: 3, Tracks: 12, Credits: 4})
+		require.Len(t, releases, 5)
+		for i, r := range releases {
+			assert.Equal(t, int32(i+1), r.ID)
+			assert.Len(t, r.Tracks, 12)
+			assert.Len(t, r.Artists, 4)
+			assert.Len(t, r.ExtraArtists, 4)
+			for _, a := range r.Artists {
+				assert.LessOrEqual(t, a.ID, int32(3))
+			}
+		}
+	})
+	t.Run("writes malformed dates by ratio", func(t *testing.T) {
+		for _, r := range write(Options{Count: 20, MalformedDates: 1}) {
+			assert.Contains(t, malformedDates, r.Released)
+		}
+		for _, r := range write(Options{Count: 20}) {
+			assert.NotContains(t, malformedDates, r.Released)
+		}
+	})
+}
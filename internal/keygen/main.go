@@ -0,0 +1,189 @@
+// Command keygen generates Key methods of models, returning their primary key columns as model.Key:
+//
+//	go run ./internal/keygen -dir model -out key.gen.go
+//
+// Primary key columns are those of gorm tags with primaryKey, as helper.ExtractGormPKColumns lists them.
+// Models of which primary key is auto incremented are keyed by columns of their unique index instead, as rows are
+// written before the database assigns them.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const maxInts = 3
+
+func main() {
+	var (
+		dir = flag.String("dir", ".", "directory of models")
+		out = flag.String("out", "key.gen.go", "file to generate in dir")
+	)
+	flag.Parse()
+
+	src, err := generate(*dir)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(*dir, *out), src, 0644)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// column is a key column of a model.
+type column struct {
+	name  string
+	field string
+	typ   string
+}
+
+// generate returns source of Key methods of every model in dir having key columns, in order of model names.
+func generate(dir string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string][]column)
+	pkgName := ""
+	for name, pkg := range pkgs {
+		pkgName = name
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					st, ok := ts.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					if columns := keyColumns(st); len(columns) > 0 {
+						keys[ts.Name.Name] = columns
+					}
+				}
+			}
+		}
+	}
+
+	models := make([]string, 0, len(keys))
+	for name := range keys {
+		models = append(models, name)
+	}
+	sort.Strings(models)
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("// Code generated by internal/keygen. DO NOT EDIT.\n\n")
+	buf.WriteString("package " + pkgName + "\n")
+	for _, name := range models {
+		body, err := keyOf(keys[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key of %+v: %w", name, err)
+		}
+		names := make([]string, len(keys[name]))
+		for i, c := range keys[name] {
+			names[i] = c.name
+		}
+		_, _ = fmt.Fprintf(buf, "\n// Key returns Key of %s, of %s.\nfunc (m *%s) Key() Key {\n\treturn %s\n}\n",
+			name, strings.Join(names, ", "), name, body)
+	}
+	return format.Source(buf.Bytes())
+}
+
+// keyColumns returns primary key columns of st, or columns of its unique index if the primary key is auto incremented.
+func keyColumns(st *ast.StructType) []column {
+	var (
+		pk            = make([]column, 0)
+		unique        = make([]column, 0)
+		autoIncrement = false
+	)
+	for _, field := range st.Fields.List {
+		if field.Tag == nil || len(field.Names) == 0 {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		gorm, ok := reflect.StructTag(tag).Lookup("gorm")
+		if !ok {
+			continue
+		}
+		c := column{field: field.Names[0].Name, typ: typeName(field.Type)}
+		for _, part := range strings.Split(gorm, ";") {
+			if strings.HasPrefix(part, "column:") {
+				c.name = strings.TrimPrefix(part, "column:")
+			}
+		}
+		if len(c.name) == 0 || c.name == "updated_at" {
+			continue
+		}
+		if strings.Contains(gorm, "primaryKey") {
+			pk = append(pk, c)
+			autoIncrement = autoIncrement || strings.Contains(gorm, "autoIncrement:true")
+		} else if strings.Contains(gorm, "uniqueIndex") {
+			unique = append(unique, c)
+		}
+	}
+	if autoIncrement && len(unique) > 0 {
+		return unique
+	}
+	return pk
+}
+
+func typeName(expr ast.Expr) string {
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// keyOf returns expression of Key of columns, with integer columns in Ints in order and a string column as Text.
+func keyOf(columns []column) (string, error) {
+	var (
+		ints = make([]string, 0, maxInts)
+		text = ""
+	)
+	for _, c := range columns {
+		switch c.typ {
+		case "int64":
+			ints = append(ints, "m."+c.field)
+		case "int16", "int32":
+			ints = append(ints, "int64(m."+c.field+")")
+		case "string":
+			if len(text) > 0 {
+				return "", fmt.Errorf("more than a text column: %+v", c.name)
+			}
+			text = "m." + c.field
+		default:
+			return "", fmt.Errorf("unsupported type of column %+v: %+v", c.name, c.typ)
+		}
+	}
+	if len(ints) > maxInts {
+		return "", fmt.Errorf("more than %d integer columns", maxInts)
+	}
+	fields := make([]string, 0, 2)
+	if len(ints) > 0 {
+		fields = append(fields, fmt.Sprintf("Ints: [%d]int64{%s}", maxInts, strings.Join(ints, ", ")))
+	}
+	if len(text) > 0 {
+		fields = append(fields, "Text: "+text)
+	}
+	return "Key{" + strings.Join(fields, ", ") + "}", nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateIsUpToDate(t *testing.T) {
+	src, err := generate("../../model")
+	require.NoError(t, err)
+	current, err := os.ReadFile("../../model/key.gen.go")
+	require.NoError(t, err)
+	require.Equal(t, string(current), string(src), "run go generate ./model")
+}
+
+func TestGenerate(t *testing.T) {
+	write := func(t *testing.T, src string) string {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "model.go"), []byte(src), 0644))
+		return dir
+	}
+
+	t.Run("keys by primary key columns", func(t *testing.T) {
+		src, err := generate(write(t, "package model\n\ntype Track struct {\n"+
+			"\tReleaseID int32 `gorm:\"column:release_id;primaryKey\"`\n"+
+			"\tTitle string `gorm:\"column:title\"`\n"+
+			"\tTitleHash int64 `gorm:\"column:title_hash;primaryKey\"`\n"+
+			"\tUpdatedAt int64 `gorm:\"column:updated_at;primaryKey\"`\n}\n"))
+		require.NoError(t, err)
+		require.Contains(t, string(src), "// Key returns Key of Track, of release_id, title_hash.")
+		require.Contains(t, string(src), "return Key{Ints: [3]int64{int64(m.ReleaseID), m.TitleHash}}")
+	})
+	t.Run("keys by unique index if primary key is auto incremented", func(t *testing.T) {
+		src, err := generate(write(t, "package model\n\ntype Genre struct {\n"+
+			"\tID int32 `gorm:\"column:id;primaryKey;autoIncrement:true\"`\n"+
+			"\tName string `gorm:\"column:name;uniqueIndex:genre_name_key\"`\n}\n"))
+		require.NoError(t, err)
+		require.Contains(t, string(src), "return Key{Text: m.Name}")
+	})
+	t.Run("rejects unsupported columns", func(t *testing.T) {
+		_, err := generate(write(t, "package model\n\ntype Image struct {\n"+
+			"\tRatio float64 `gorm:\"column:ratio;primaryKey\"`\n}\n"))
+		require.ErrorContains(t, err, "unsupported type of column ratio")
+	})
+}
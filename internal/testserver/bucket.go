@@ -0,0 +1,70 @@
+package testserver
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type bucketListing struct {
+	XMLName     xml.Name         `xml:"ListBucketResult"`
+	Name        string           `xml:"Name"`
+	Marker      string           `xml:"Marker"`
+	MaxKeys     int              `xml:"MaxKeys"`
+	IsTruncated bool             `xml:"IsTruncated"`
+	Contents    []bucketContents `xml:"Contents"`
+}
+
+type bucketContents struct {
+	Key  string `xml:"Key"`
+	ETag string `xml:"ETag"`
+	Size int    `xml:"Size"`
+}
+
+// NewBucketServer returns TestServer that fakes a S3 bucket holding given objects, keyed by their path.
+// Bucket root lists at most maxKeys per page, continued by the marker query as S3 ListObjects (v1) does.
+// Any other path serves the object content, or 404 if missing.
+func NewBucketServer(objects map[string]string, maxKeys int) *TestServer {
+	keys := make([]string, 0, len(objects))
+	for k := range objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return NewServer(func(requests []*HttpRequest, w http.ResponseWriter, r *http.Request) {
+		if p := strings.TrimPrefix(r.URL.Path, "/"); len(p) > 0 {
+			if content, ok := objects[p]; ok {
+				_, _ = w.Write([]byte(content))
+			} else {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			return
+		}
+
+		marker := r.URL.Query().Get("marker")
+		begin := sort.SearchStrings(keys, marker)
+		if begin < len(keys) && keys[begin] == marker {
+			begin++
+		}
+		end := begin + maxKeys
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		listing := bucketListing{Name: "discogs-data-dumps", Marker: marker, MaxKeys: maxKeys, IsTruncated: end < len(keys)}
+		for _, k := range keys[begin:end] {
+			sum := md5.Sum([]byte(objects[k]))
+			listing.Contents = append(listing.Contents, bucketContents{
+				Key:  k,
+				ETag: strconv.Quote(hex.EncodeToString(sum[:])),
+				Size: len(objects[k]),
+			})
+		}
+		b, _ := xml.Marshal(listing)
+		_, _ = w.Write(append([]byte(xml.Header), b...))
+	})
+}
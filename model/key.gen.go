@@ -0,0 +1,138 @@
+// Code generated by internal/keygen. DO NOT EDIT.
+
+package model
+
+// Key returns Key of Artist, of id.
+func (m *Artist) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ID)}}
+}
+
+// Key returns Key of ArtistAlias, of artist_id, alias_id.
+func (m *ArtistAlias) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ArtistID), int64(m.AliasID)}}
+}
+
+// Key returns Key of ArtistGroup, of artist_id, group_id.
+func (m *ArtistGroup) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ArtistID), int64(m.GroupID)}}
+}
+
+// Key returns Key of ArtistNameVariation, of artist_id, name_variation_hash.
+func (m *ArtistNameVariation) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ArtistID), m.NameVariationHash}}
+}
+
+// Key returns Key of ArtistURL, of artist_id, url_hash.
+func (m *ArtistURL) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ArtistID), m.URLHash}}
+}
+
+// Key returns Key of Data, of etag.
+func (m *Data) Key() Key {
+	return Key{Text: m.Etag}
+}
+
+// Key returns Key of Genre, of name.
+func (m *Genre) Key() Key {
+	return Key{Text: m.Name}
+}
+
+// Key returns Key of Label, of id.
+func (m *Label) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ID)}}
+}
+
+// Key returns Key of LabelRelease, of label_id, release_id.
+func (m *LabelRelease) Key() Key {
+	return Key{Ints: [3]int64{int64(m.LabelID), int64(m.ReleaseID)}}
+}
+
+// Key returns Key of LabelURL, of label_id, url_hash.
+func (m *LabelURL) Key() Key {
+	return Key{Ints: [3]int64{int64(m.LabelID), m.URLHash}}
+}
+
+// Key returns Key of Master, of id.
+func (m *Master) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ID)}}
+}
+
+// Key returns Key of MasterArtist, of artist_id, master_id.
+func (m *MasterArtist) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ArtistID), int64(m.MasterID)}}
+}
+
+// Key returns Key of MasterGenre, of master_id, genre_id.
+func (m *MasterGenre) Key() Key {
+	return Key{Ints: [3]int64{int64(m.MasterID), int64(m.GenreID)}}
+}
+
+// Key returns Key of MasterStyle, of master_id, style_id.
+func (m *MasterStyle) Key() Key {
+	return Key{Ints: [3]int64{int64(m.MasterID), int64(m.StyleID)}}
+}
+
+// Key returns Key of MasterVideo, of master_id, url_hash.
+func (m *MasterVideo) Key() Key {
+	return Key{Ints: [3]int64{int64(m.MasterID), m.URLHash}}
+}
+
+// Key returns Key of Release, of id.
+func (m *Release) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ID)}}
+}
+
+// Key returns Key of ReleaseArtist, of release_id, artist_id.
+func (m *ReleaseArtist) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ReleaseID), int64(m.ArtistID)}}
+}
+
+// Key returns Key of ReleaseContract, of release_id, label_id, contract_hash.
+func (m *ReleaseContract) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ReleaseID), int64(m.LabelID), m.ContractHash}}
+}
+
+// Key returns Key of ReleaseCreditedArtist, of release_id, artist_id, role_hash.
+func (m *ReleaseCreditedArtist) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ReleaseID), int64(m.ArtistID), m.RoleHash}}
+}
+
+// Key returns Key of ReleaseFormat, of release_id, format_hash.
+func (m *ReleaseFormat) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ReleaseID), m.FormatHash}}
+}
+
+// Key returns Key of ReleaseGenre, of release_id, genre_id.
+func (m *ReleaseGenre) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ReleaseID), int64(m.GenreID)}}
+}
+
+// Key returns Key of ReleaseIdentifier, of release_id, identifier_hash.
+func (m *ReleaseIdentifier) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ReleaseID), m.IdentifierHash}}
+}
+
+// Key returns Key of ReleaseImage, of release_id, url_hash.
+func (m *ReleaseImage) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ReleaseID), m.URLHash}}
+}
+
+// Key returns Key of ReleaseStyle, of release_id, style_id.
+func (m *ReleaseStyle) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ReleaseID), int64(m.StyleID)}}
+}
+
+// Key returns Key of ReleaseTrack, of release_id, title_hash.
+func (m *ReleaseTrack) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ReleaseID), m.TitleHash}}
+}
+
+// Key returns Key of ReleaseVideo, of release_id, url_hash.
+func (m *ReleaseVideo) Key() Key {
+	return Key{Ints: [3]int64{int64(m.ReleaseID), m.URLHash}}
+}
+
+// Key returns Key of Style, of name.
+func (m *Style) Key() Key {
+	return Key{Text: m.Name}
+}
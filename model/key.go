@@ -0,0 +1,16 @@
+package model
+
+//go:generate go run ../internal/keygen -dir . -out key.gen.go
+
+// Key identifies a row of a model by its primary key columns, as helper.ExtractGormPKColumns lists them.
+// Integer columns are held by Ints in order of columns, and a text column by Text.
+// Models of which primary key is assigned by the database, such as Genre, are keyed by their unique index instead.
+type Key struct {
+	Ints [3]int64
+	Text string
+}
+
+// Keyer is a model of which rows are identified by Key, such as those deduped by unique.Slice.
+type Keyer interface {
+	Key() Key
+}
@@ -0,0 +1,232 @@
+// Package discogs streams entities of Discogs data dumps, with no dependency on the database layer.
+//
+// Each iterator decodes one element at a time, so that dumps of any size are read in constant memory:
+//
+//	it, err := discogs.OpenReleases(ctx, "discogs_20240101_releases.xml.gz")
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		release := it.Value()
+//		...
+//	}
+//	return it.Err()
+package discogs
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/state303/go-discogs/src/decompress"
+	"io"
+	"os"
+)
+
+// Element names of each entity in dump files.
+const (
+	ArtistElement  = "artist"
+	LabelElement   = "label"
+	MasterElement  = "master"
+	ReleaseElement = "release"
+)
+
+// Gzip implementations to decompress gzip compressed dumps with.
+const (
+	// GzipStandard decompresses on a single goroutine with compress/gzip.
+	GzipStandard = decompress.GzipStandard
+	// GzipParallel decompresses with klauspost/pgzip, which reads ahead and checks blocks on goroutines of its own.
+	GzipParallel = decompress.GzipParallel
+)
+
+// Options configures how Iterator reads a dump. Zero value is the default.
+type Options struct {
+	// Gzip is the implementation to decompress gzip compressed dumps with. Empty means GzipStandard.
+	Gzip string
+}
+
+// DecodeError is returned when an element cannot be decoded. Iteration stops at the first DecodeError.
+type DecodeError struct {
+	// Element is the local name of the element.
+	Element string
+	// Offset is the input offset the element ends at, in bytes of decompressed XML.
+	Offset int64
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode %+v at offset %+v: %+v", e.Element, e.Offset, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Iterator reads entities of type T from a dump, one at a time. It is not safe for concurrent use.
+type Iterator[T any] struct {
+	ctx       context.Context
+	decoder   *xml.Decoder
+	closers   []io.Closer
+	localName string
+	value     *T
+	err       error
+}
+
+// NewIterator returns Iterator decoding each element of localName in r into T. Nested elements of the same
+// name, such as label of a release, are not matched, as the element is decoded as a whole.
+// Gzip or zstd compressed r is decompressed. r is closed by Close if it is io.Closer.
+func NewIterator[T any](ctx context.Context, r io.Reader, localName string) *Iterator[T] {
+	return NewIteratorWithOptions[T](ctx, r, localName, Options{})
+}
+
+// NewIteratorWithOptions returns Iterator as NewIterator, reading r as configured by opts.
+func NewIteratorWithOptions[T any](ctx context.Context, r io.Reader, localName string, opts Options) *Iterator[T] {
+	it := &Iterator[T]{ctx: ctx, localName: localName}
+	if c, ok := r.(io.Closer); ok {
+		it.closers = append(it.closers, c)
+	}
+	if err := decompress.ValidGzip(opts.Gzip); err != nil {
+		it.err = err
+		return it
+	}
+	src, err := decompress.Reader(r, opts.Gzip)
+	if err != nil {
+		it.err = err
+		return it
+	}
+	it.closers = append(it.closers, src)
+	it.decoder = xml.NewDecoder(src)
+	return it
+}
+
+// Next decodes the next entity, returning false at the end of the dump, on error or on cancellation of ctx.
+func (it *Iterator[T]) Next() bool {
+	it.value = nil
+	if it.err != nil {
+		return false
+	}
+	for {
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		token, err := it.decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return false
+		} else if err != nil {
+			it.err = err
+			return false
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != it.localName {
+			continue
+		}
+		v := new(T)
+		if err = it.decoder.DecodeElement(v, &start); err != nil {
+			it.err = &DecodeError{Element: it.localName, Offset: it.decoder.InputOffset(), Err: err}
+			return false
+		}
+		it.value = v
+		return true
+	}
+}
+
+// Value returns the entity decoded by the last call to Next.
+func (it *Iterator[T]) Value() *T {
+	return it.value
+}
+
+// Err returns the error that stopped iteration, if any. Cancellation of ctx is reported as its error.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close closes the underlying reader, along with its decompressor.
+func (it *Iterator[T]) Close() error {
+	var err error
+	for i := len(it.closers) - 1; i >= 0; i-- {
+		if cErr := it.closers[i].Close(); err == nil {
+			err = cErr
+		}
+	}
+	it.closers = nil
+	return err
+}
+
+// Stream sends every entity of it into the returned channel, which is closed once it stops, closing it as well.
+// The error channel receives the error that stopped it, if any, then is closed.
+func Stream[T any](it *Iterator[T]) (<-chan *T, <-chan error) {
+	values, errs := make(chan *T), make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(values)
+		defer func() { _ = it.Close() }()
+		for it.Next() {
+			select {
+			case values <- it.Value():
+			case <-it.ctx.Done():
+				errs <- it.ctx.Err()
+				return
+			}
+		}
+		if err := it.Err(); err != nil {
+			errs <- err
+		}
+	}()
+	return values, errs
+}
+
+// NewArtists returns Iterator of artists read from r.
+func NewArtists(ctx context.Context, r io.Reader) *Iterator[Artist] {
+	return NewIterator[Artist](ctx, r, ArtistElement)
+}
+
+// NewLabels returns Iterator of labels read from r.
+func NewLabels(ctx context.Context, r io.Reader) *Iterator[Label] {
+	return NewIterator[Label](ctx, r, LabelElement)
+}
+
+// NewMasters returns Iterator of masters read from r.
+func NewMasters(ctx context.Context, r io.Reader) *Iterator[Master] {
+	return NewIterator[Master](ctx, r, MasterElement)
+}
+
+// NewReleases returns Iterator of releases read from r.
+func NewReleases(ctx context.Context, r io.Reader) *Iterator[Release] {
+	return NewIterator[Release](ctx, r, ReleaseElement)
+}
+
+// OpenArtists returns Iterator of artists in dump file at path, either gzip or zstd compressed, or plain.
+func OpenArtists(ctx context.Context, path string) (*Iterator[Artist], error) {
+	return Open[Artist](ctx, path, ArtistElement, Options{})
+}
+
+// OpenLabels returns Iterator of labels in dump file at path, either gzip or zstd compressed, or plain.
+func OpenLabels(ctx context.Context, path string) (*Iterator[Label], error) {
+	return Open[Label](ctx, path, LabelElement, Options{})
+}
+
+// OpenMasters returns Iterator of masters in dump file at path, either gzip or zstd compressed, or plain.
+func OpenMasters(ctx context.Context, path string) (*Iterator[Master], error) {
+	return Open[Master](ctx, path, MasterElement, Options{})
+}
+
+// OpenReleases returns Iterator of releases in dump file at path, either gzip or zstd compressed, or plain.
+func OpenReleases(ctx context.Context, path string) (*Iterator[Release], error) {
+	return Open[Release](ctx, path, ReleaseElement, Options{})
+}
+
+// Open returns Iterator decoding each element of localName in dump file at path into T, as NewIteratorWithOptions.
+func Open[T any](ctx context.Context, path, localName string, opts Options) (*Iterator[T], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	it := NewIteratorWithOptions[T](ctx, f, localName, opts)
+	if err = it.Err(); err != nil {
+		_ = it.Close()
+		return nil, fmt.Errorf("failed to read %+v: %w", path, err)
+	}
+	return it, nil
+}
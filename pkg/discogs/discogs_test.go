@@ -0,0 +1,167 @@
+package discogs
+
+import (
+	"bytes"
+	"context"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+)
+
+func collect[T any](t *testing.T, it *Iterator[T]) []*T {
+	t.Helper()
+	defer func() { require.NoError(t, it.Close()) }()
+	items := make([]*T, 0)
+	for it.Next() {
+		items = append(items, it.Value())
+	}
+	require.NoError(t, it.Err())
+	return items
+}
+
+func TestOpenArtists(t *testing.T) {
+	it, err := OpenArtists(context.Background(), "testdata/artist.xml.gz")
+	require.NoError(t, err)
+	artists := collect(t, it)
+	require.Len(t, artists, 3)
+
+	a := artists[1]
+	assert.Equal(t, int32(2), a.ID)
+	assert.Equal(t, "Mr. James Barth & A.D.", a.Name)
+	assert.Equal(t, "Correct", a.DataQuality)
+	assert.Len(t, a.NameVariations, 4)
+	assert.Equal(t, []Ref{{ID: 1, Name: "Puente Latino"}}, a.Aliases)
+	assert.Equal(t, []Ref{{ID: 3, Name: "Alexi Delano"}}, a.Members)
+}
+
+func TestOpenLabels(t *testing.T) {
+	it, err := OpenLabels(context.Background(), "testdata/label.xml")
+	require.NoError(t, err)
+	labels := collect(t, it)
+	require.Len(t, labels, 5)
+
+	l := labels[0]
+	assert.Equal(t, int32(1), l.ID)
+	assert.Equal(t, "Planet E", l.Name)
+	assert.Len(t, l.Images, 7)
+	assert.Equal(t, Image{Type: "primary", Width: 132, Height: 24}, l.Images[0])
+	assert.Contains(t, l.URLs, "http://planet-e.net")
+	assert.Contains(t, l.SubLabels, Ref{ID: 86537, Name: "Antidote (4)"})
+}
+
+func TestOpenMasters(t *testing.T) {
+	it, err := OpenMasters(context.Background(), "testdata/master.xml.gz")
+	require.NoError(t, err)
+	masters := collect(t, it)
+	require.Len(t, masters, 3)
+
+	m := masters[0]
+	assert.Equal(t, int32(1), m.ID)
+	assert.Equal(t, int32(1), m.MainRelease)
+	assert.Equal(t, "Moments In Time", m.Title)
+	assert.Equal(t, int16(2002), m.Year)
+	assert.Equal(t, []string{"Electronic"}, m.Genres)
+	assert.Equal(t, []string{"Techno", "Tech House"}, m.Styles)
+	assert.Equal(t, "Vince Watson", m.Artists[0].Name)
+	require.NotEmpty(t, m.Videos)
+	assert.Equal(t, "https://www.youtube.com/watch?v=9Vm2AcMdSoA", m.Videos[0].URL)
+	assert.Equal(t, 358, m.Videos[0].Duration)
+	assert.True(t, m.Videos[0].Embed)
+}
+
+func TestOpenReleases(t *testing.T) {
+	it, err := OpenReleases(context.Background(), "testdata/release.xml.gz")
+	require.NoError(t, err)
+	releases := collect(t, it)
+	require.Len(t, releases, 3)
+
+	r := releases[0]
+	assert.Equal(t, int32(1), r.ID)
+	assert.Equal(t, "Accepted", r.Status)
+	assert.Equal(t, "Stockholm", r.Title)
+	assert.Equal(t, "Sweden", r.Country)
+	assert.Equal(t, "1999-03-00", r.Released)
+	assert.Equal(t, ReleaseMaster{ID: 1, IsMainRelease: true}, r.Master)
+	assert.Equal(t, []ReleaseLabel{{ID: 1, Name: "Svek", CatNo: "SK032"}}, r.Labels)
+	assert.Equal(t, "The Persuader", r.Artists[0].Name)
+	assert.Equal(t, "Music By [All Tracks By]", r.ExtraArtists[0].Role)
+	require.Len(t, r.Formats, 1)
+	assert.Equal(t, "Vinyl", r.Formats[0].Name)
+	assert.Equal(t, "2", r.Formats[0].Quantity)
+	assert.Len(t, r.Formats[0].Descriptions, 2)
+	require.Len(t, r.Tracks, 6)
+	assert.Equal(t, Track{Position: "A", Title: "Östermalm", Duration: "4:45"}, r.Tracks[0])
+	assert.Len(t, r.Identifiers, 5)
+	assert.Equal(t, "Matrix / Runout", r.Identifiers[0].Type)
+	assert.NotEmpty(t, r.Videos)
+	require.NotEmpty(t, r.Companies)
+	assert.Equal(t, "Recorded At", r.Companies[0].EntityTypeName)
+	assert.Equal(t, int32(23), r.Companies[0].EntityType)
+}
+
+func TestIterator(t *testing.T) {
+	t.Run("stops on cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		it := NewLabels(ctx, strings.NewReader(`<labels><label><id>1</id></label><label><id>2</id></label></labels>`))
+		require.True(t, it.Next())
+		cancel()
+		require.False(t, it.Next())
+		require.ErrorIs(t, it.Err(), context.Canceled)
+	})
+	t.Run("reports element failed to decode", func(t *testing.T) {
+		it := NewLabels(context.Background(), strings.NewReader(`<labels><label><id>1</id></label><label><id>x</id></label></labels>`))
+		require.True(t, it.Next())
+		require.False(t, it.Next())
+		var decodeErr *DecodeError
+		require.ErrorAs(t, it.Err(), &decodeErr)
+		assert.Equal(t, LabelElement, decodeErr.Element)
+		assert.NotZero(t, decodeErr.Offset)
+	})
+	t.Run("reads zstd compressed input", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		w, err := zstd.NewWriter(buf)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(`<labels><label><id>1</id></label><label><id>2</id></label></labels>`))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		labels := collect(t, NewLabels(context.Background(), buf))
+		require.Len(t, labels, 2)
+		assert.Equal(t, int32(2), labels[1].ID)
+	})
+	t.Run("reads gzip compressed input with pgzip", func(t *testing.T) {
+		it, err := Open[Artist](context.Background(), "testdata/artist.xml.gz", ArtistElement, Options{Gzip: GzipParallel})
+		require.NoError(t, err)
+		require.Len(t, collect(t, it), 3)
+	})
+	t.Run("fails on unknown gzip implementation", func(t *testing.T) {
+		_, err := Open[Artist](context.Background(), "testdata/artist.xml.gz", ArtistElement, Options{Gzip: "lz4"})
+		require.ErrorContains(t, err, "unknown gzip implementation")
+	})
+	t.Run("reads empty input", func(t *testing.T) {
+		it := NewArtists(context.Background(), strings.NewReader(""))
+		require.False(t, it.Next())
+		require.NoError(t, it.Err())
+	})
+}
+
+func TestStream(t *testing.T) {
+	f, err := os.Open("testdata/release.xml.gz")
+	require.NoError(t, err)
+	values, errs := Stream(NewReleases(context.Background(), f))
+	ids := make([]int32, 0)
+	for r := range values {
+		ids = append(ids, r.ID)
+	}
+	require.NoError(t, <-errs)
+	assert.Len(t, ids, 3)
+
+	t.Run("reports error", func(t *testing.T) {
+		values, errs := Stream(NewReleases(context.Background(), strings.NewReader(`<releases><release id="x"></release></releases>`)))
+		for range values {
+		}
+		require.Error(t, <-errs)
+	})
+}
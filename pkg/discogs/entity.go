@@ -0,0 +1,149 @@
+package discogs
+
+// Ref is a reference to another entity by id, along with its name as listed.
+type Ref struct {
+	ID   int32  `xml:"id,attr"`
+	Name string `xml:",chardata"`
+}
+
+// Image is an image listed on an entity. Uris are empty in public dumps.
+type Image struct {
+	Type   string `xml:"type,attr"`
+	URI    string `xml:"uri,attr"`
+	URI150 string `xml:"uri150,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+// Video is a video listed on a master or release.
+type Video struct {
+	URL         string `xml:"src,attr"`
+	Duration    int    `xml:"duration,attr"`
+	Embed       bool   `xml:"embed,attr"`
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+}
+
+// ArtistCredit is an artist credited on a master, release or track.
+// Anv is the artist name variation credited, and Join joins it to the next artist, such as &.
+type ArtistCredit struct {
+	ID     int32  `xml:"id"`
+	Name   string `xml:"name"`
+	Anv    string `xml:"anv"`
+	Join   string `xml:"join"`
+	Role   string `xml:"role"`
+	Tracks string `xml:"tracks"`
+}
+
+// Artist is an artist element of the artists dump.
+type Artist struct {
+	ID             int32    `xml:"id"`
+	Name           string   `xml:"name"`
+	RealName       string   `xml:"realname"`
+	Profile        string   `xml:"profile"`
+	DataQuality    string   `xml:"data_quality"`
+	Images         []Image  `xml:"images>image"`
+	URLs           []string `xml:"urls>url"`
+	NameVariations []string `xml:"namevariations>name"`
+	Aliases        []Ref    `xml:"aliases>name"`
+	Members        []Ref    `xml:"members>name"`
+	Groups         []Ref    `xml:"groups>name"`
+}
+
+// Label is a label element of the labels dump.
+type Label struct {
+	ID          int32    `xml:"id"`
+	Name        string   `xml:"name"`
+	ContactInfo string   `xml:"contactinfo"`
+	Profile     string   `xml:"profile"`
+	DataQuality string   `xml:"data_quality"`
+	Images      []Image  `xml:"images>image"`
+	URLs        []string `xml:"urls>url"`
+	ParentLabel *Ref     `xml:"parentLabel"`
+	SubLabels   []Ref    `xml:"sublabels>label"`
+}
+
+// Master is a master element of the masters dump, which groups releases of the same work.
+type Master struct {
+	ID          int32          `xml:"id,attr"`
+	MainRelease int32          `xml:"main_release"`
+	Title       string         `xml:"title"`
+	Year        int16          `xml:"year"`
+	DataQuality string         `xml:"data_quality"`
+	Images      []Image        `xml:"images>image"`
+	Artists     []ArtistCredit `xml:"artists>artist"`
+	Genres      []string       `xml:"genres>genre"`
+	Styles      []string       `xml:"styles>style"`
+	Videos      []Video        `xml:"videos>video"`
+}
+
+// Release is a release element of the releases dump.
+type Release struct {
+	ID           int32          `xml:"id,attr"`
+	Status       string         `xml:"status,attr"`
+	Title        string         `xml:"title"`
+	Country      string         `xml:"country"`
+	Released     string         `xml:"released"`
+	Notes        string         `xml:"notes"`
+	DataQuality  string         `xml:"data_quality"`
+	Master       ReleaseMaster  `xml:"master_id"`
+	Images       []Image        `xml:"images>image"`
+	Artists      []ArtistCredit `xml:"artists>artist"`
+	ExtraArtists []ArtistCredit `xml:"extraartists>artist"`
+	Labels       []ReleaseLabel `xml:"labels>label"`
+	Formats      []Format       `xml:"formats>format"`
+	Genres       []string       `xml:"genres>genre"`
+	Styles       []string       `xml:"styles>style"`
+	Tracks       []Track        `xml:"tracklist>track"`
+	Identifiers  []Identifier   `xml:"identifiers>identifier"`
+	Videos       []Video        `xml:"videos>video"`
+	Companies    []Company      `xml:"companies>company"`
+}
+
+// ReleaseMaster is the master of a release. ID is zero if the release has none.
+type ReleaseMaster struct {
+	ID            int32 `xml:",chardata"`
+	IsMainRelease bool  `xml:"is_main_release,attr"`
+}
+
+// ReleaseLabel is a label a release is published on, with its catalog number.
+type ReleaseLabel struct {
+	ID    int32  `xml:"id,attr"`
+	Name  string `xml:"name,attr"`
+	CatNo string `xml:"catno,attr"`
+}
+
+// Format is a physical or digital format of a release, such as Vinyl.
+type Format struct {
+	Name         string   `xml:"name,attr"`
+	Quantity     string   `xml:"qty,attr"`
+	Text         string   `xml:"text,attr"`
+	Descriptions []string `xml:"descriptions>description"`
+}
+
+// Track is a track of a release. Heading and index tracks have sub tracks.
+type Track struct {
+	Position     string         `xml:"position"`
+	Title        string         `xml:"title"`
+	Duration     string         `xml:"duration"`
+	Artists      []ArtistCredit `xml:"artists>artist"`
+	ExtraArtists []ArtistCredit `xml:"extraartists>artist"`
+	SubTracks    []Track        `xml:"sub_tracks>track"`
+}
+
+// Identifier is an identifier of a release, such as barcode or matrix.
+type Identifier struct {
+	Type        string `xml:"type,attr"`
+	Description string `xml:"description,attr"`
+	Value       string `xml:"value,attr"`
+}
+
+// Company is a company credited on a release, such as a studio it is recorded at.
+type Company struct {
+	ID             int32  `xml:"id"`
+	Name           string `xml:"name"`
+	CatNo          string `xml:"catno"`
+	EntityType     int32  `xml:"entity_type"`
+	EntityTypeName string `xml:"entity_type_name"`
+	ResourceURL    string `xml:"resource_url"`
+}
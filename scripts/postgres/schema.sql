@@ -3,7 +3,9 @@ CREATE TABLE "data" (
                         "generated_at" TIMESTAMP NOT NULL,
                         "checksum" VARCHAR(200) NOT NULL,
                         "target_type" VARCHAR(20) NOT NULL,
-                        "uri" VARCHAR(2048) NOT NULL
+                        "uri" VARCHAR(2048) NOT NULL,
+                        "size" BIGINT NOT NULL DEFAULT 0,
+                        "loaded_at" TIMESTAMP
 );
 
 CREATE TABLE "style" (
@@ -245,6 +247,10 @@ Always uppercase.';
 
 COMMENT ON COLUMN "data"."uri" IS 'URI to download dump data file.';
 
+COMMENT ON COLUMN "data"."size" IS 'Size of dump data file in bytes, as listed by the source.';
+
+COMMENT ON COLUMN "data"."loaded_at" IS 'Time this data is loaded successfully. Null if never loaded.';
+
 COMMENT ON COLUMN "style"."id" IS 'id of style';
 
 COMMENT ON COLUMN "style"."name" IS 'name of style';
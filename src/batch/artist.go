@@ -1,15 +1,12 @@
 package batch
 
 import (
-	"context"
 	"fmt"
 	"github.com/state303/go-discogs/model"
-	"github.com/state303/go-discogs/src/helper"
 	"github.com/state303/go-discogs/src/reader"
 	"github.com/state303/go-discogs/src/result"
 	"io"
 	"os"
-	"sync"
 	"time"
 )
 
@@ -35,82 +32,41 @@ func insertArtists(order Order) result.Result {
 }
 
 func insertArtistRelations(order Order) result.Result {
-	r := newReadCloser(order.getFilePath(), "updating artist relations...")
-
-	var (
-		wg   = new(sync.WaitGroup)
-		res  = make(chan result.Result)
-		done = make(chan struct{}, 1)
-	)
-
-	go func() {
-		<-reader.NewReader[XmlArtistRelation](context.Background(), r, "artist").
-			WindowWithCount(order.getChunkSize()).
-			Map(helper.MapWindowedSlice[*XmlArtistRelation]()).
-			ForEach(
-				writeArtistRelations(order, res, wg),
-				printError(),
-				signalDone(done, wg))
-	}()
-
-	go func() { // wait until done called then close res chan
-		<-done
-		close(res)
-	}()
-
-	sum := result.NewResult(0, nil)
-	for next := range res {
-		if next == nil {
-			continue
-		}
-		sum = sum.Sum(next)
+	begin := time.Now()
+	r, err := newReadCloser(order.getFilePath(), order.getGzip(), "updating artist relations...")
+	if err != nil {
+		return result.NewResult(0, err)
 	}
-
-	fmt.Printf("\nUpdated %+v artist relations\n", sum.Count())
+	sum := runPipeline(order, readElements[XmlArtistRelation](order, r, "artist", "artist relations"), prepareArtistRelations)
+	logUpdated("artist relations", sum.Count(), begin)
 	return sum
 }
 
-func writeArtistRelations(order Order, res chan result.Result, wg *sync.WaitGroup) func(i interface{}) {
-	return func(i interface{}) {
-		wg.Add(1)
-		items := i.([]*XmlArtistRelation)
-		n := make([]*model.ArtistNameVariation, 0)
-		a := make([]*model.ArtistAlias, 0)
-		g := make([]*model.ArtistGroup, 0)
-		u := make([]*model.ArtistURL, 0)
-		for _, item := range items {
-			a = append(a, item.GetAliases()...)
-			g = append(g, item.GetGroups()...)
-			n = append(n, item.GetNameVars()...)
-			u = append(u, item.GetUrls()...)
-		}
-		go func(res chan result.Result) {
-			defer wg.Done()
-			res <- writeThenReport(order, wg, a, g, n, u)
-		}(res)
+func prepareArtistRelations(items []*XmlArtistRelation) (chunkWriter, error) {
+	n := make([]*model.ArtistNameVariation, 0)
+	a := make([]*model.ArtistAlias, 0)
+	g := make([]*model.ArtistGroup, 0)
+	u := make([]*model.ArtistURL, 0)
+	for _, item := range items {
+		a = append(a, item.GetAliases()...)
+		g = append(g, item.GetGroups()...)
+		n = append(n, item.GetNameVars()...)
+		u = append(u, item.GetUrls()...)
 	}
+	return link(a, g, n, u), nil
 }
 
-func printError() func(err error) {
-	return func(err error) {
-		fmt.Printf("[ERROR] %+v %+v\n", time.Now().Format(time.Layout), err)
+// newReadCloser opens dump file at filepath, either gzip or zstd compressed or plain, reporting progress with
+// progressBarText. Gzip is decompressed with gzipImpl, as decompress.Reader.
+func newReadCloser(filepath, gzipImpl, progressBarText string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
 	}
-}
-
-func newReadCloser(filepath string, progressBarText string) io.ReadCloser {
-	if f, err := os.Open(filepath); err != nil {
-		panic(err)
-	} else if r, err := reader.NewProgressBarGzipReadCloser(f, progressBarText); err != nil {
+	r, err := reader.NewProgressBarReadCloser(f, progressBarText, gzipImpl)
+	if err != nil {
 		_ = f.Close()
-		panic(r)
-	} else {
-		return r
-	}
-}
-
-func insertBySlice[T any](order Order) func(_ context.Context, i interface{}) (interface{}, error) {
-	return func(_ context.Context, i interface{}) (interface{}, error) {
-		res := NewWriter(order.getDB()).Write(order.getChunkSize(), i.([]T))
-		return res.Count(), res.Err()
+		return nil, fmt.Errorf("failed to read %+v: %w", filepath, err)
 	}
+	return r, nil
 }
@@ -0,0 +1,138 @@
+package batch
+
+import (
+	"fmt"
+	"github.com/state303/go-discogs/src/database"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// shadowSuffix names the schema a dump is loaded into under atomic mode, such as public_shadow.
+const shadowSuffix = "_shadow"
+
+// bookkeepingTables returns tables kept in the target schema rather than swapped, as they outlive each load.
+func bookkeepingTables() []string {
+	return []string{database.TableName("data"), database.TableName("dead_letter")}
+}
+
+// Shadow is a fresh schema to load a dump into, whose tables replace ones of the target schema at once.
+type Shadow struct {
+	// DB connects to the shadow schema.
+	DB     *gorm.DB
+	db     *gorm.DB
+	target string
+	name   string
+}
+
+// NewShadow creates a fresh shadow schema of the current schema of db, running given phases of the DDL script,
+// or every phase if none given. Shadow schema left from a former failed load is dropped first.
+func NewShadow(db *gorm.DB, dsn string, phases ...DDLPhase) (*Shadow, error) {
+	var target string
+	if err := db.Raw("SELECT current_schema()").Scan(&target).Error; err != nil {
+		return nil, err
+	}
+	s := &Shadow{db: db, target: target, name: target + shadowSuffix}
+	if err := s.Drop(); err != nil {
+		return nil, err
+	}
+	if err := db.Exec(fmt.Sprintf("CREATE SCHEMA %+v", database.QuoteIdentifier(s.name))).Error; err != nil {
+		return nil, err
+	}
+	shadowDB, err := database.GetConnect(database.WithSearchPath(dsn, s.name))
+	if err != nil {
+		return nil, err
+	}
+	if len(phases) == 0 {
+		phases = []DDLPhase{PhaseTables, PhaseIndexes, PhaseConstraints}
+	}
+	if err = RunDDLPhases(shadowDB, phases...); err != nil {
+		return nil, err
+	}
+	s.DB = shadowDB
+	return s, nil
+}
+
+// Swap moves every table of the shadow schema into the target schema in a single transaction,
+// replacing tables of the same name. Consumers see either the former tables or the new ones, never a mix.
+// Former tables are dropped without cascade, so the swap fails rather than dropping views, functions or foreign keys
+// of consumers depending on them.
+func (s *Shadow) Swap() error {
+	var tables []string
+	err := s.db.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = ? AND table_type = 'BASE TABLE' AND NOT (table_name IN ?)",
+		s.name, bookkeepingTables()).Scan(&tables).Error
+	if err != nil {
+		return err
+	}
+	dependents, err := s.findDependents(tables)
+	if err != nil {
+		return err
+	} else if len(dependents) > 0 {
+		return fmt.Errorf("failed to swap %+v into %+v: objects depend on tables to replace: %+v",
+			s.name, s.target, strings.Join(dependents, ", "))
+	}
+	retired := s.target + "_retired"
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		stmts := []string{
+			fmt.Sprintf("DROP SCHEMA IF EXISTS %+v", database.QuoteIdentifier(retired)),
+			fmt.Sprintf("CREATE SCHEMA %+v", database.QuoteIdentifier(retired)),
+		}
+		retiredTables := make([]string, 0, len(tables))
+		for _, t := range tables {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE IF EXISTS %+v.%+v SET SCHEMA %+v", database.QuoteIdentifier(s.target), database.QuoteIdentifier(t), database.QuoteIdentifier(retired)))
+			retiredTables = append(retiredTables, database.QuoteIdentifier(retired)+"."+database.QuoteIdentifier(t))
+		}
+		for _, t := range tables {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %+v.%+v SET SCHEMA %+v", database.QuoteIdentifier(s.name), database.QuoteIdentifier(t), database.QuoteIdentifier(s.target)))
+		}
+		if len(retiredTables) > 0 {
+			stmts = append(stmts, fmt.Sprintf("DROP TABLE IF EXISTS %+v", strings.Join(retiredTables, ", ")))
+		}
+		stmts = append(stmts, fmt.Sprintf("DROP SCHEMA %+v", database.QuoteIdentifier(retired)))
+		for _, stmt := range stmts {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to swap %+v into %+v: %w", s.name, s.target, err)
+	}
+	return s.Drop()
+}
+
+// findDependents describes objects depending on given tables of the target schema, such as views of consumers or
+// their foreign keys, which would be dropped along with the tables. Foreign keys among the tables are not listed.
+func (s *Shadow) findDependents(tables []string) ([]string, error) {
+	dependents := make([]string, 0)
+	if len(tables) == 0 {
+		return dependents, nil
+	}
+	err := s.db.Raw(`SELECT DISTINCT pg_describe_object(d.classid, d.objid, d.objsubid)
+FROM pg_depend d
+JOIN pg_class t ON t.oid = d.refobjid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+LEFT JOIN pg_constraint c ON d.classid = 'pg_constraint'::regclass AND c.oid = d.objid
+LEFT JOIN pg_class ct ON ct.oid = c.conrelid
+WHERE d.refclassid = 'pg_class'::regclass AND d.deptype = 'n'
+  AND n.nspname = @schema AND t.relname IN @tables
+  AND NOT (ct.oid IS NOT NULL AND ct.relnamespace = n.oid AND ct.relname IN @tables)`,
+		map[string]interface{}{"schema": s.target, "tables": tables}).Scan(&dependents).Error
+	return dependents, err
+}
+
+// Drop closes connection to the shadow schema, then removes the schema along with whatever loaded into it.
+func (s *Shadow) Drop() error {
+	if s.DB != nil {
+		if sqlDB, err := s.DB.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+		s.DB = nil
+	}
+	return s.db.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %+v CASCADE", database.QuoteIdentifier(s.name))).Error
+}
+
+// Name returns name of the shadow schema.
+func (s *Shadow) Name() string {
+	return s.name
+}
@@ -0,0 +1,47 @@
+package batch
+
+import (
+	"github.com/state303/go-discogs/internal/testutils"
+	"github.com/state303/go-discogs/model"
+	"github.com/state303/go-discogs/src/database"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestShadowSwap(t *testing.T) {
+	pg := testutils.GetDatabase(testutils.Postgres)
+	dsn := testutils.GetDsn(testutils.Postgres, pg)
+	require.NoError(t, database.Connect(dsn))
+	db := database.DB
+	require.NoError(t, RunDDL(db))
+
+	require.NoError(t, db.Create(&model.Genre{Name: "former"}).Error)
+
+	shadow, err := NewShadow(db, dsn)
+	require.NoError(t, err)
+	require.NoError(t, shadow.DB.Create(&model.Genre{Name: "swapped"}).Error)
+
+	var names []string
+	db.Session(&gorm.Session{}).Model(&model.Genre{}).Pluck("name", &names)
+	require.Equal(t, []string{"former"}, names, "shadow must not be visible before swap")
+
+	require.NoError(t, shadow.Swap())
+	names = nil
+	db.Session(&gorm.Session{}).Model(&model.Genre{}).Pluck("name", &names)
+	require.Equal(t, []string{"swapped"}, names)
+	require.True(t, db.Migrator().HasTable("data"), "bookkeeping tables must remain")
+
+	t.Run("fails while consumer objects depend on tables", func(t *testing.T) {
+		require.NoError(t, db.Exec("CREATE VIEW genre_names AS SELECT name FROM genre").Error)
+		shadow, err := NewShadow(db, dsn)
+		require.NoError(t, err)
+		err = shadow.Swap()
+		require.ErrorContains(t, err, "genre_names")
+		require.NoError(t, shadow.Drop())
+
+		names = nil
+		db.Session(&gorm.Session{}).Table("genre_names").Pluck("name", &names)
+		require.Equal(t, []string{"swapped"}, names, "dependent view must remain on former tables")
+	})
+}
@@ -1,13 +1,36 @@
 package batch
 
 import (
+	"context"
 	"github.com/state303/go-discogs/src/database"
 	"github.com/state303/go-discogs/src/result"
+	"github.com/state303/go-discogs/src/tracing"
 	"gorm.io/gorm"
 )
 
 type Step func() result.Result
 
+// runSteps runs steps in order until one fails or ctx is cancelled, calling onStep with index, count and error of each step run.
+// Readers stop silently on cancellation, hence a step ended by cancellation is regarded as failed with ctx error,
+// even though chunks in flight are committed.
+func runSteps(ctx context.Context, steps []Step, onStep func(i, count int, err error)) (total int, err error) {
+	for i := range steps {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		r := steps[i]()
+		total += r.Count()
+		if err = r.Err(); err == nil {
+			err = ctx.Err()
+		}
+		onStep(i, r.Count(), err)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
 type Batch interface {
 	UpdateArtist(order Order) Step
 	UpdateLabel(order Order) Step
@@ -28,17 +51,27 @@ var newBatch = func() Batch {
 }
 
 func (b *batch) UpdateArtist(order Order) Step {
-	return GetArtistStep(order)
+	return tracedStep("artists", order, GetArtistStep)
 }
 
 func (b *batch) UpdateLabel(order Order) Step {
-	return GetLabelStep(order)
+	return tracedStep("labels", order, GetLabelStep)
 }
 
 func (b *batch) UpdateMaster(order Order) Step {
-	return GetMasterStep(order)
+	return tracedStep("masters", order, GetMasterStep)
 }
 
 func (b *batch) UpdateRelease(order Order) Step {
-	return GetReleaseStep(order)
+	return tracedStep("releases", order, GetReleaseStep)
+}
+
+// tracedStep returns Step of newStep running under a span of its own, so that spans of its chunks are children of it.
+func tracedStep(typ string, order Order, newStep func(Order) Step) Step {
+	return func() result.Result {
+		ctx, span := tracing.Start(order.getContext(), "step", tracing.KeyType.String(typ))
+		r := newStep(order.withContext(ctx))()
+		tracing.End(span, r.Count(), r.Err())
+		return r
+	}
 }
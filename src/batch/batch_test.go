@@ -2,12 +2,21 @@ package batch
 
 import (
 	"context"
+	"errors"
 	"github.com/state303/go-discogs/internal/testutils"
 	"github.com/state303/go-discogs/model"
+	"github.com/state303/go-discogs/src/cache"
 	"github.com/state303/go-discogs/src/database"
+	"github.com/state303/go-discogs/src/result"
+	"github.com/state303/go-discogs/src/tracing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"gorm.io/gorm"
+	"sync"
 	"testing"
 )
 
@@ -79,6 +88,37 @@ func TestBatch(t *testing.T) {
 	require.NotZero(t, count)
 }
 
+func TestResumeWarmsIDCaches(t *testing.T) {
+	pg := testutils.GetDatabase(testutils.Postgres)
+	db, err := database.GetConnect(testutils.GetDsn(testutils.Postgres, pg))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// an interrupted run completed artists and labels
+	require.NoError(t, newBatch().UpdateArtist(NewOrder(ctx, 5, "testdata/artist.xml.gz", db))().Err())
+	require.NoError(t, newBatch().UpdateLabel(NewOrder(ctx, 5, "testdata/label.xml.gz", db))().Err())
+	for _, c := range []*sync.Map{cache.ArtistIDCache, cache.LabelIDCache, cache.MasterIDCache} {
+		c.Range(func(key, _ any) bool {
+			c.Delete(key)
+			return true
+		})
+	}
+	all := db.Session(&gorm.Session{AllowGlobalUpdate: true})
+	require.NoError(t, all.Delete(&model.ReleaseArtist{}).Error)
+	require.NoError(t, all.Delete(&model.LabelRelease{}).Error)
+
+	// the resumed run skips them, loading the rest
+	require.NoError(t, warmResumedCaches(db, map[string]bool{"artists": true, "labels": true}))
+	require.NoError(t, newBatch().UpdateMaster(NewOrder(ctx, 5, "testdata/master.xml.gz", db))().Err())
+	require.NoError(t, newBatch().UpdateRelease(NewOrder(ctx, 5, "testdata/release.xml.gz", db))().Err())
+
+	var count int64
+	db.Session(&gorm.Session{}).Model(&model.ReleaseArtist{}).Count(&count)
+	require.NotZero(t, count, "release_artist must refer to artists of the interrupted run")
+	db.Session(&gorm.Session{}).Model(&model.LabelRelease{}).Count(&count)
+	require.NotZero(t, count, "label_release must refer to labels of the interrupted run")
+}
+
 func Test_batch_UpdateLabel(t *testing.T) {
 	type fields struct {
 		db *gorm.DB
@@ -147,3 +187,73 @@ func Test_batch_UpdateRelease(t *testing.T) {
 		})
 	}
 }
+
+func TestRunSteps(t *testing.T) {
+	step := func(count int, err error) Step {
+		return func() result.Result { return result.NewResult(count, err) }
+	}
+
+	t.Run("runs every step in order", func(t *testing.T) {
+		completed := make([]int, 0)
+		total, err := runSteps(context.Background(), []Step{step(1, nil), step(2, nil)}, func(i, _ int, err error) {
+			require.NoError(t, err)
+			completed = append(completed, i)
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, total)
+		require.Equal(t, []int{0, 1}, completed)
+	})
+	t.Run("stops at failed step", func(t *testing.T) {
+		ran := 0
+		total, err := runSteps(context.Background(), []Step{step(1, errors.New("failed")), step(2, nil)}, func(_, count int, err error) {
+			require.Equal(t, 1, count)
+			require.ErrorContains(t, err, "failed")
+			ran++
+		})
+		require.ErrorContains(t, err, "failed")
+		require.Equal(t, 1, total)
+		require.Equal(t, 1, ran)
+	})
+	t.Run("step ended by cancellation is not completed", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		completed := 0
+		cancelling := func() result.Result {
+			cancel()
+			return result.NewResult(5, nil)
+		}
+		total, err := runSteps(ctx, []Step{cancelling, step(2, nil)}, func(_, _ int, err error) {
+			if err == nil {
+				completed++
+			}
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 5, total)
+		require.Zero(t, completed)
+	})
+}
+
+func TestTracedStep(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	order := NewOrder(context.Background(), 5, "", nil)
+	step := tracedStep("artists", order, func(order Order) Step {
+		return func() result.Result {
+			_, chunk := tracing.Start(order.getContext(), "chunk")
+			tracing.End(chunk, 2, nil)
+			return result.NewResult(2, errors.New("failed"))
+		}
+	})
+	require.ErrorContains(t, step().Err(), "failed")
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	chunk, parent := spans[0], spans[1]
+	assert.Equal(t, "step", parent.Name)
+	assert.Contains(t, parent.Attributes, tracing.KeyType.String("artists"))
+	assert.Contains(t, parent.Attributes, tracing.KeyCount.Int(2))
+	assert.Equal(t, codes.Error, parent.Status.Code)
+	assert.Equal(t, parent.SpanContext.SpanID(), chunk.Parent.SpanID())
+}
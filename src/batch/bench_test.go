@@ -0,0 +1,154 @@
+package batch
+
+import (
+	"compress/gzip"
+	"context"
+	"github.com/state303/go-discogs/internal/dumpgen"
+	"github.com/state303/go-discogs/internal/testutils"
+	"github.com/state303/go-discogs/src/cache"
+	"github.com/state303/go-discogs/src/database"
+	"github.com/state303/go-discogs/src/decompress"
+	"github.com/state303/go-discogs/src/xmlparser"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// benchOptions shapes synthetic dumps of benchmarks. Elements refer to ids of each other, as in real dumps.
+var benchOptions = dumpgen.Options{Seed: 1, Count: 2000, Tracks: 12, Credits: 3, Unicode: true, MalformedDates: 0.05}
+
+// generateDump writes gzip compressed synthetic dump of typ into a temp dir of b, returning its path.
+func generateDump(b *testing.B, typ string) string {
+	b.Helper()
+	path := filepath.Join(b.TempDir(), "discogs_20240101_"+typ+".xml.gz")
+	f, err := os.Create(path)
+	require.NoError(b, err)
+	w := gzip.NewWriter(f)
+	require.NoError(b, dumpgen.Write(w, typ, benchOptions))
+	require.NoError(b, w.Close())
+	require.NoError(b, f.Close())
+	return path
+}
+
+// readAll decodes every element of localName in dump at path on workers goroutines.
+func readAll[T any](b *testing.B, path, localName string, workers int) []*T {
+	b.Helper()
+	f, err := os.Open(path)
+	require.NoError(b, err)
+	defer func() { _ = f.Close() }()
+	r, err := decompress.Reader(f, decompress.GzipStandard)
+	require.NoError(b, err)
+	items, err := readParallel[T](r, localName, xmlparser.ParallelOptions{Workers: workers, Ordered: true})
+	require.NoError(b, err)
+	require.Len(b, items, benchOptions.Count)
+	return items
+}
+
+// warmIDCaches caches ids every element of benchOptions may refer to, as earlier steps do.
+func warmIDCaches() {
+	for id := int32(1); id <= int32(benchOptions.Count); id++ {
+		cache.ArtistIDCache.Store(id, struct{}{})
+		cache.LabelIDCache.Store(id, struct{}{})
+		cache.MasterIDCache.Store(id, struct{}{})
+	}
+}
+
+// BenchmarkParse decodes synthetic dumps of each type into elements read by steps, on a single and on default
+// number of workers.
+func BenchmarkParse(b *testing.B) {
+	parsers := []struct {
+		typ   string
+		parse func(b *testing.B, path string, workers int)
+	}{
+		{dumpgen.TypeArtists, func(b *testing.B, path string, workers int) {
+			readAll[XmlArtistRelation](b, path, "artist", workers)
+		}},
+		{dumpgen.TypeLabels, func(b *testing.B, path string, workers int) {
+			readAll[XmlLabelRelation](b, path, "label", workers)
+		}},
+		{dumpgen.TypeMasters, func(b *testing.B, path string, workers int) {
+			readAll[XmlMasterRelation](b, path, "master", workers)
+		}},
+		{dumpgen.TypeReleases, func(b *testing.B, path string, workers int) {
+			readAll[XmlReleaseRelation](b, path, "release", workers)
+		}},
+	}
+	for _, p := range parsers {
+		path := generateDump(b, p.typ)
+		for _, workers := range []struct {
+			name  string
+			count int
+		}{{"single", 1}, {"parallel", 0}} {
+			b.Run(p.typ+"/"+workers.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					p.parse(b, path, workers.count)
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkTransform prepares rows of every chunk of decoded elements, including dedupe of relations by
+// unique.Slice, with no writes.
+func BenchmarkTransform(b *testing.B) {
+	warmIDCaches()
+	order := NewOrderWithSink(context.Background(), 1000, "", newMemorySink(), NewErrorHandler(PolicyFail, nil))
+	b.Run(dumpgen.TypeArtists, func(b *testing.B) {
+		benchmarkTransform(b, readAll[XmlArtistRelation](b, generateDump(b, dumpgen.TypeArtists), "artist", 0), prepareArtistRelations)
+	})
+	b.Run(dumpgen.TypeLabels, func(b *testing.B) {
+		benchmarkTransform(b, readAll[XmlLabelRelation](b, generateDump(b, dumpgen.TypeLabels), "label", 0), prepareLabelRelations)
+	})
+	b.Run(dumpgen.TypeMasters, func(b *testing.B) {
+		benchmarkTransform(b, readAll[XmlMasterRelation](b, generateDump(b, dumpgen.TypeMasters), "master", 0), prepareMasterRelations(order))
+	})
+	b.Run(dumpgen.TypeReleases, func(b *testing.B) {
+		benchmarkTransform(b, readAll[XmlReleaseRelation](b, generateDump(b, dumpgen.TypeReleases), "release", 0), prepareReleases(order))
+	})
+}
+
+func benchmarkTransform[T any](b *testing.B, items []*T, prepare func(items []*T) (chunkWriter, error)) {
+	const chunkSize = 1000
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for from := 0; from < len(items); from += chunkSize {
+			to := from + chunkSize
+			if to > len(items) {
+				to = len(items)
+			}
+			if _, err := prepare(items[from:to]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkWrite runs every step on synthetic dumps into postgres, which is started as a container.
+func BenchmarkWrite(b *testing.B) {
+	paths := make(map[string]string)
+	for _, typ := range []string{dumpgen.TypeArtists, dumpgen.TypeLabels, dumpgen.TypeMasters, dumpgen.TypeReleases} {
+		paths[typ] = generateDump(b, typ)
+	}
+	pg := testutils.GetDatabase(testutils.Postgres)
+	require.NoError(b, database.Connect(testutils.GetDsn(testutils.Postgres, pg)))
+	db := database.DB
+	require.NoError(b, RunDDL(db))
+
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		steps := []Step{
+			newBatch().UpdateArtist(NewOrder(ctx, 1000, paths[dumpgen.TypeArtists], db)),
+			newBatch().UpdateLabel(NewOrder(ctx, 1000, paths[dumpgen.TypeLabels], db)),
+			newBatch().UpdateMaster(NewOrder(ctx, 1000, paths[dumpgen.TypeMasters], db)),
+			newBatch().UpdateRelease(NewOrder(ctx, 1000, paths[dumpgen.TypeReleases], db)),
+		}
+		for _, step := range steps {
+			if res := step(); res.IsErr() {
+				b.Fatal(res.Err())
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package batch
+
+import (
+	"fmt"
+	"github.com/knadh/koanf"
+	"github.com/sirupsen/logrus"
+	"github.com/state303/go-discogs/src/data"
+	"github.com/state303/go-discogs/src/helper"
+	"github.com/state303/go-discogs/src/logging"
+	"github.com/state303/go-discogs/src/retention"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CleanRunner removes dump files beyond the last N generations of each types from data dir.
+type CleanRunner struct{}
+
+func (*CleanRunner) Run(config *koanf.Koanf, w io.Writer) error {
+	return ApplyRetention(config.String("data"), config.Int("keep"), config.Bool("dry-run"), w)
+}
+
+// ApplyRetention keeps the newest keep generations of each types in dir, then reports disk usage to w.
+// On dryRun, files to be removed are only reported.
+func ApplyRetention(dir string, keep int, dryRun bool, w io.Writer) error {
+	verb := "removed"
+	if dryRun {
+		verb = "would remove"
+	}
+	res, err := applyRetention(dir, keep, dryRun, func(f *retention.File) {
+		_, _ = fmt.Fprintf(w, "%+v %+v (%+v)\n", verb, f.Path, helper.FormatByteSize(f.Size))
+	})
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(w, "%+v %+v files, %+v of %+v in %+v. %+v remains.\n",
+		verb, res.files, helper.FormatByteSize(res.freed), helper.FormatByteSize(res.usage), dir, helper.FormatByteSize(res.usage-res.freed))
+	return nil
+}
+
+// logRetention keeps the newest keep generations of each types in dir as ApplyRetention does,
+// reporting each removal and disk usage as log events instead.
+func logRetention(dir string, keep int) error {
+	res, err := applyRetention(dir, keep, false, func(f *retention.File) {
+		logrus.WithFields(logrus.Fields{logging.FieldFile: f.Path, logging.FieldBytes: f.Size}).Info("removed")
+	})
+	if err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{
+		logging.FieldCount: res.files,
+		logging.FieldBytes: res.freed,
+		"usage":            res.usage,
+		"dir":              dir,
+	}).Info("retention applied")
+	return nil
+}
+
+// retentionResult is number of files removed by retention, bytes they freed and bytes used before removal.
+type retentionResult struct {
+	files        int
+	freed, usage int64
+}
+
+// applyRetention removes files beyond the newest keep generations of each types in dir, unless dryRun.
+// onExpired is called with each of them before removal.
+func applyRetention(dir string, keep int, dryRun bool, onExpired func(f *retention.File)) (retentionResult, error) {
+	files, err := retention.Scan(dir)
+	if err != nil {
+		return retentionResult{}, err
+	}
+	expired := retention.SelectExpired(files, keep)
+	res := retentionResult{files: len(expired), freed: retention.Usage(expired), usage: retention.Usage(files)}
+	for _, f := range expired {
+		onExpired(f)
+	}
+	if !dryRun {
+		if res.freed, err = retention.Remove(expired); err != nil {
+			return retentionResult{}, err
+		}
+	}
+	return res, nil
+}
+
+// purgeFile removes a dump file once it is loaded. Files outside of data dir, such as ones of local dump source,
+// are left untouched. Failure is only reported, as the load itself succeeded.
+func purgeFile(dataDir, target string) {
+	log := logrus.WithField(logging.FieldFile, target)
+	if rel, err := filepath.Rel(dataDir, target); err != nil || strings.HasPrefix(rel, "..") {
+		log.Info("skipped purging: not in data dir")
+		return
+	}
+	if err := os.Remove(target); err != nil {
+		log.WithError(err).Warn("failed to purge")
+		return
+	}
+	log.Info("purged")
+}
+
+// isSourceDir tells whether dir is the local directory of given dump source, whose files must not be removed.
+func isSourceDir(source, dir string) bool {
+	sourceDir, ok := data.LocalDir(source)
+	if !ok {
+		return false
+	}
+	rel, err := filepath.Rel(dir, sourceDir)
+	return err == nil && rel == "."
+}
@@ -0,0 +1,87 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/state303/go-discogs/src/logging"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func prepareCleanDir(t *testing.T) string {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"discogs_20240101_artists.xml.gz",
+		"discogs_20240201_artists.xml.gz",
+		"discogs_20240301_artists.xml.gz",
+	} {
+		require.NoError(t, os.WriteFile(path.Join(dir, name), make([]byte, 1024), 0644))
+	}
+	return dir
+}
+
+func TestApplyRetention(t *testing.T) {
+	t.Run("dry run keeps files", func(t *testing.T) {
+		dir := prepareCleanDir(t)
+		out := new(bytes.Buffer)
+		require.NoError(t, ApplyRetention(dir, 2, true, out))
+		require.Contains(t, out.String(), "would remove "+path.Join(dir, "discogs_20240101_artists.xml.gz"))
+		require.Contains(t, out.String(), "1.0K of 3.0K")
+		require.FileExists(t, path.Join(dir, "discogs_20240101_artists.xml.gz"))
+	})
+	t.Run("removes files beyond kept generations", func(t *testing.T) {
+		dir := prepareCleanDir(t)
+		out := new(bytes.Buffer)
+		require.NoError(t, ApplyRetention(dir, 1, false, out))
+		require.Contains(t, out.String(), "removed 2 files")
+		require.Contains(t, out.String(), "1.0K remains")
+		require.NoFileExists(t, path.Join(dir, "discogs_20240201_artists.xml.gz"))
+		require.FileExists(t, path.Join(dir, "discogs_20240301_artists.xml.gz"))
+	})
+}
+
+func TestLogRetention(t *testing.T) {
+	defer func() { _ = logging.Configure(logging.FormatText, os.Stdout) }()
+	buf := new(bytes.Buffer)
+	require.NoError(t, logging.Configure(logging.FormatJSON, buf))
+
+	dir := prepareCleanDir(t)
+	require.NoError(t, logRetention(dir, 2))
+	require.NoFileExists(t, path.Join(dir, "discogs_20240101_artists.xml.gz"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2, "every line must be a log event")
+	var removed, summary map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &removed))
+	require.Equal(t, path.Join(dir, "discogs_20240101_artists.xml.gz"), removed[logging.FieldFile])
+	require.Equal(t, float64(1024), removed[logging.FieldBytes])
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &summary))
+	require.Equal(t, float64(1), summary[logging.FieldCount])
+	require.Equal(t, float64(3072), summary["usage"])
+}
+
+func TestPurgeFile(t *testing.T) {
+	dataDir, otherDir := t.TempDir(), t.TempDir()
+	inside, outside := path.Join(dataDir, "dump.xml.gz"), path.Join(otherDir, "dump.xml.gz")
+	require.NoError(t, os.WriteFile(inside, []byte("x"), 0644))
+	require.NoError(t, os.WriteFile(outside, []byte("x"), 0644))
+
+	purgeFile(dataDir, inside)
+	purgeFile(dataDir, outside)
+	require.NoFileExists(t, inside)
+	require.FileExists(t, outside)
+}
+
+func TestIsSourceDir(t *testing.T) {
+	dir := t.TempDir()
+	require.True(t, isSourceDir(dir, dir))
+	require.True(t, isSourceDir("file://"+dir+"/", dir))
+	require.True(t, isSourceDir(path.Join(dir, "sub", ".."), dir))
+	require.False(t, isSourceDir(path.Join(dir, "sub"), dir))
+	require.False(t, isSourceDir("", dir))
+	require.False(t, isSourceDir("s3", dir))
+	require.False(t, isSourceDir("https://example.com/data", dir))
+}
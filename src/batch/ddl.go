@@ -1,35 +1,154 @@
 package batch
 
 import (
+	"fmt"
+	"github.com/sirupsen/logrus"
 	"github.com/state303/go-discogs/src/database"
+	"github.com/state303/go-discogs/src/logging"
 	"gorm.io/gorm"
 	"os"
 	"path"
 	"regexp"
 	"strings"
+	"time"
 )
 
+// DDLPhase groups statements of the DDL script by when they run.
+type DDLPhase int
+
+const (
+	// PhaseTables creates tables along with their primary and unique keys required by upserts.
+	PhaseTables DDLPhase = iota
+	// PhaseIndexes creates secondary indexes.
+	PhaseIndexes
+	// PhaseConstraints adds foreign keys.
+	PhaseConstraints
+)
+
+// identifier matches a name either bare, double-quoted, or backtick-quoted as in the MySQL script, where names are
+// padded by spaces within backticks.
+const identifier = "[\"`]?\\s*(\\w+)\\s*[\"`]?"
+
+var (
+	indexPattern      = regexp.MustCompile(`(?i)^CREATE\s+(UNIQUE\s+)?INDEX\s+` + identifier)
+	foreignKeyPattern = regexp.MustCompile(`(?i)^ALTER\s+TABLE\s+` + identifier + `\s+ADD\s+CONSTRAINT\s+` + identifier + `\s+FOREIGN\s+KEY`)
+)
+
+// RunDDL runs every phase of the DDL script in order.
 func RunDDL(db *gorm.DB) error {
-	if sql, err := ReadScript(); err != nil {
+	return RunDDLPhases(db, PhaseTables, PhaseIndexes, PhaseConstraints)
+}
+
+// RunDDLPhases runs statements of given phases from the DDL script, in order of phases.
+func RunDDLPhases(db *gorm.DB, phases ...DDLPhase) error {
+	sql, err := ReadScript()
+	if err != nil {
 		return err
-	} else {
-		parts := strings.Split(sql, ";")
-		for i := range parts {
-			q := strings.TrimSpace(parts[i])
-			if len(q) == 0 {
-				continue
-			}
+	}
+	statements := SplitDDL(sql)
+	for _, phase := range phases {
+		for _, q := range statements[phase] {
 			if err := db.Session(&gorm.Session{}).Exec(q).Error; err != nil {
 				return err
 			}
 		}
+	}
+	return nil
+}
+
+// RunDeferredDDL builds indexes then adds foreign keys after a load with PhaseTables only, reporting progress of each.
+// On Postgres, foreign keys are added NOT VALID then validated separately, so that adding them skips the full scan
+// under lock. Constraints failed to validate remain NOT VALID, and are reported as error.
+func RunDeferredDDL(db *gorm.DB) error {
+	sql, err := ReadScript()
+	if err != nil {
+		return err
+	}
+	statements := SplitDDL(sql)
+	notValid := database.Kind == database.Postgres
+
+	indexes, constraints := statements[PhaseIndexes], statements[PhaseConstraints]
+	for i, q := range indexes {
+		name := q
+		if m := indexPattern.FindStringSubmatch(q); m != nil {
+			name = m[2]
+		}
+		if err = runWithProgress(db, q, fmt.Sprintf("[%+v/%+v] building index %+v", i+1, len(indexes), name)); err != nil {
+			return err
+		}
+	}
+
+	type fk struct{ table, name string }
+	added := make([]fk, 0)
+	for i, q := range constraints {
+		m := foreignKeyPattern.FindStringSubmatch(q)
+		if notValid && m != nil {
+			q += " NOT VALID"
+		}
+		desc := fmt.Sprintf("[%+v/%+v] adding constraint", i+1, len(constraints))
+		if m != nil {
+			desc += " " + m[2]
+			added = append(added, fk{m[1], m[2]})
+		}
+		if err = runWithProgress(db, q, desc); err != nil {
+			return err
+		}
+	}
+	if !notValid {
 		return nil
 	}
+
+	failed := 0
+	for i, c := range added {
+		q := fmt.Sprintf("ALTER TABLE %+v VALIDATE CONSTRAINT %+v", database.QuoteIdentifier(c.table), database.QuoteIdentifier(c.name))
+		if err = runWithProgress(db, q, fmt.Sprintf("[%+v/%+v] validating %+v", i+1, len(added), c.name)); err != nil {
+			logrus.WithField("constraint", c.name).WithError(err).Warn("constraint remains NOT VALID")
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%+v of %+v constraints failed validation", failed, len(added))
+	}
+	return nil
+}
+
+func runWithProgress(db *gorm.DB, q, desc string) error {
+	begin := time.Now()
+	log := logrus.WithField(logging.FieldStep, desc)
+	log.Info("running DDL...")
+	err := db.Session(&gorm.Session{}).Exec(q).Error
+	log = log.WithField(logging.FieldElapsed, logging.Elapsed(begin))
+	if err != nil {
+		log.WithError(err).Error("DDL failed")
+		return err
+	}
+	log.Info("DDL done")
+	return nil
+}
+
+// SplitDDL splits the DDL script into statements of each phase, keeping their order within the phase.
+func SplitDDL(sql string) map[DDLPhase][]string {
+	res := make(map[DDLPhase][]string)
+	for _, part := range strings.Split(sql, ";") {
+		q := strings.TrimSpace(part)
+		if len(q) == 0 {
+			continue
+		}
+		phase := PhaseTables
+		if indexPattern.MatchString(q) {
+			phase = PhaseIndexes
+		} else if foreignKeyPattern.MatchString(q) {
+			phase = PhaseConstraints
+		}
+		res[phase] = append(res[phase], q)
+	}
+	return res
 }
 
+// ReadScript reads the DDL script, with names prefixed by database.Names.
 func ReadScript() (string, error) {
 	v, err := os.ReadFile(GetScriptPath())
-	return string(v), err
+	return database.Names.RewriteDDL(string(v)), err
 }
 
 func GetScriptPath() string {
@@ -0,0 +1,49 @@
+package batch
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSplitDDL(t *testing.T) {
+	b, err := os.ReadFile("../../scripts/postgres/schema.sql")
+	require.NoError(t, err)
+	statements := SplitDDL(string(b))
+
+	require.NotEmpty(t, statements[PhaseTables])
+	for _, q := range statements[PhaseTables] {
+		require.NotContains(t, q, "FOREIGN KEY")
+	}
+	require.Len(t, statements[PhaseIndexes], 1)
+	require.True(t, strings.HasPrefix(statements[PhaseIndexes][0], `CREATE INDEX "pk_style"`))
+	require.NotEmpty(t, statements[PhaseConstraints])
+	for _, q := range statements[PhaseConstraints] {
+		require.Regexp(t, foreignKeyPattern, q)
+	}
+}
+
+func TestSplitDDLMySQL(t *testing.T) {
+	b, err := os.ReadFile("../../scripts/mysql/schema.sql")
+	require.NoError(t, err)
+	statements := SplitDDL(string(b))
+
+	require.NotEmpty(t, statements[PhaseTables])
+	for _, q := range statements[PhaseTables] {
+		require.NotContains(t, q, "FOREIGN KEY")
+	}
+	require.Len(t, statements[PhaseIndexes], 1)
+	require.Equal(t, "pk_style", indexPattern.FindStringSubmatch(statements[PhaseIndexes][0])[2])
+	require.NotEmpty(t, statements[PhaseConstraints])
+	for _, q := range statements[PhaseConstraints] {
+		require.Regexp(t, foreignKeyPattern, q)
+	}
+	m := foreignKeyPattern.FindStringSubmatch(statements[PhaseConstraints][0])
+	require.Equal(t, []string{"label", "fk_label_parent_id_label_id"}, m[1:])
+}
+
+func TestGetInitialPhases(t *testing.T) {
+	require.Equal(t, []DDLPhase{PhaseTables}, getInitialPhases(true))
+	require.Equal(t, []DDLPhase{PhaseTables, PhaseIndexes, PhaseConstraints}, getInitialPhases(false))
+}
@@ -0,0 +1,160 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/state303/go-discogs/src/deadletter"
+	"github.com/state303/go-discogs/src/metrics"
+	"github.com/state303/go-discogs/src/xmlparser"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrorPolicy decides what to do with malformed elements and rows rejected by the database.
+type ErrorPolicy string
+
+const (
+	// PolicyFail stops the step at the first error.
+	PolicyFail ErrorPolicy = "fail"
+	// PolicySkip skips failed elements and rows, counting them only.
+	PolicySkip ErrorPolicy = "skip"
+	// PolicyDeadLetter skips failed elements and rows, writing them into dead-letter sink.
+	PolicyDeadLetter ErrorPolicy = "deadletter"
+)
+
+// ParseErrorPolicy returns ErrorPolicy of s, where empty s is PolicyFail.
+func ParseErrorPolicy(s string) (ErrorPolicy, error) {
+	switch p := ErrorPolicy(strings.ToLower(s)); p {
+	case "":
+		return PolicyFail, nil
+	case PolicyFail, PolicySkip, PolicyDeadLetter:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown error policy: %+v", s)
+}
+
+// ErrorHandler applies ErrorPolicy on failures while reading elements or writing rows.
+// It is shared among steps, hence safe for concurrent use.
+type ErrorHandler interface {
+	// HandleElement handles an element failed to decode. Returns err if the step must stop.
+	HandleElement(topic string, err *xmlparser.ElementError) error
+	// HandleRow handles a row rejected by the database. Returns err if the step must stop.
+	HandleRow(row interface{}, err error) error
+	// Policy returns ErrorPolicy being applied.
+	Policy() ErrorPolicy
+	// Count returns number of elements and rows skipped so far.
+	Count() int
+}
+
+// NewErrorHandler returns ErrorHandler of given policy. Sink is required only for PolicyDeadLetter.
+func NewErrorHandler(policy ErrorPolicy, sink deadletter.Sink) ErrorHandler {
+	return &errorHandler{policy: policy, sink: sink}
+}
+
+type errorHandler struct {
+	mu     sync.Mutex
+	policy ErrorPolicy
+	sink   deadletter.Sink
+	count  int
+}
+
+func (h *errorHandler) HandleElement(topic string, err *xmlparser.ElementError) error {
+	return h.handle(err, &deadletter.Record{
+		Topic:    topic,
+		SourceID: err.ID,
+		Error:    err.Err.Error(),
+		Payload:  elementPayload(err),
+	})
+}
+
+// maxElementPayload caps bytes of a malformed element kept as dead-letter payload.
+const maxElementPayload = 64 * 1024
+
+// elementPayload returns raw bytes of the element of err, truncated to maxElementPayload on a rune boundary.
+// Only the name of the element is returned if its raw bytes are unknown.
+func elementPayload(err *xmlparser.ElementError) string {
+	if len(err.Raw) == 0 {
+		return "<" + err.Name + ">"
+	}
+	raw := err.Raw
+	if len(raw) > maxElementPayload {
+		n := maxElementPayload
+		for n > 0 && !utf8.RuneStart(raw[n]) {
+			n--
+		}
+		raw = raw[:n]
+	}
+	return string(raw)
+}
+
+func (h *errorHandler) HandleRow(row interface{}, err error) error {
+	payload, _ := json.Marshal(row)
+	return h.handle(err, &deadletter.Record{
+		Topic:    getTypeName(row),
+		SourceID: getRowID(row),
+		Error:    err.Error(),
+		Payload:  string(payload),
+	})
+}
+
+func (h *errorHandler) handle(err error, r *deadletter.Record) error {
+	if h.policy == PolicyFail || len(h.policy) == 0 {
+		return err
+	}
+	if h.policy == PolicyDeadLetter && h.sink != nil {
+		r.CreatedAt = time.Now()
+		if sinkErr := h.sink.Write(r); sinkErr != nil {
+			return fmt.Errorf("failed to write dead-letter: %w", sinkErr)
+		}
+	}
+	h.mu.Lock()
+	h.count++
+	h.mu.Unlock()
+	metrics.RejectedRecords.WithLabelValues(r.Topic, string(h.policy)).Inc()
+	return nil
+}
+
+func (h *errorHandler) Policy() ErrorPolicy {
+	return h.policy
+}
+
+func (h *errorHandler) Count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+func getTypeName(row interface{}) string {
+	t := reflect.TypeOf(row)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// getRowID returns ID field of row, or the first field ending with ID such as ReleaseID.
+func getRowID(row interface{}) string {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if f := v.FieldByName("ID"); f.IsValid() {
+		return fieldString(f)
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if strings.HasSuffix(v.Type().Field(i).Name, "ID") {
+			return fieldString(v.Field(i))
+		}
+	}
+	return ""
+}
+
+func fieldString(f reflect.Value) string {
+	if f.Kind() == reflect.Pointer && f.IsNil() {
+		return ""
+	}
+	return fmt.Sprint(reflect.Indirect(f).Interface())
+}
@@ -0,0 +1,72 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/state303/go-discogs/model"
+	"github.com/state303/go-discogs/src/deadletter"
+	"github.com/state303/go-discogs/src/xmlparser"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseErrorPolicy(t *testing.T) {
+	for s, expected := range map[string]ErrorPolicy{"": PolicyFail, "fail": PolicyFail, "Skip": PolicySkip, "deadletter": PolicyDeadLetter} {
+		p, err := ParseErrorPolicy(s)
+		require.NoError(t, err)
+		require.Equal(t, expected, p)
+	}
+	_, err := ParseErrorPolicy("ignore")
+	require.ErrorContains(t, err, "unknown error policy")
+}
+
+func TestErrorHandler(t *testing.T) {
+	elemErr := &xmlparser.ElementError{Name: "artist", ID: "42", Err: errors.New("bad id"), Raw: []byte(`<artist><id>x</id></artist>`)}
+	rowErr := errors.New("violates constraint")
+
+	t.Run("fail returns err", func(t *testing.T) {
+		h := NewErrorHandler(PolicyFail, nil)
+		require.ErrorIs(t, h.HandleElement("artists", elemErr), elemErr)
+		require.ErrorIs(t, h.HandleRow(&model.Artist{ID: 1}, rowErr), rowErr)
+		require.Zero(t, h.Count())
+	})
+	t.Run("skip counts only", func(t *testing.T) {
+		h := NewErrorHandler(PolicySkip, nil)
+		require.NoError(t, h.HandleElement("artists", elemErr))
+		require.NoError(t, h.HandleRow(&model.Artist{ID: 1}, rowErr))
+		require.Equal(t, 2, h.Count())
+	})
+	t.Run("deadletter writes records with source id", func(t *testing.T) {
+		p := path.Join(t.TempDir(), "deadletter.jsonl")
+		sink := deadletter.NewFileSink(p)
+		h := NewErrorHandler(PolicyDeadLetter, sink)
+		require.NoError(t, h.HandleElement("artists", elemErr))
+		require.NoError(t, h.HandleRow(&model.ArtistAlias{ArtistID: 7, AliasID: 8}, rowErr))
+		require.NoError(t, sink.Close())
+		require.Equal(t, 2, h.Count())
+
+		b, err := os.ReadFile(p)
+		require.NoError(t, err)
+		require.Contains(t, string(b), `"topic":"artists","source_id":"42","error":"bad id"`)
+		require.Contains(t, string(b), `"topic":"ArtistAlias","source_id":"7","error":"violates constraint"`)
+
+		var r deadletter.Record
+		require.NoError(t, json.Unmarshal(bytes.SplitN(b, []byte("\n"), 2)[0], &r))
+		require.Equal(t, `<artist><id>x</id></artist>`, r.Payload)
+	})
+}
+
+func TestElementPayload(t *testing.T) {
+	require.Equal(t, "<artist>", elementPayload(&xmlparser.ElementError{Name: "artist"}))
+
+	raw := append([]byte("<artist>"), bytes.Repeat([]byte("가"), maxElementPayload)...)
+	payload := elementPayload(&xmlparser.ElementError{Name: "artist", Raw: raw})
+	require.LessOrEqual(t, len(payload), maxElementPayload)
+	require.True(t, utf8.ValidString(payload), "must not cut a rune")
+	require.True(t, strings.HasPrefix(string(raw), payload))
+}
@@ -2,6 +2,7 @@ package batch
 
 import (
 	"github.com/state303/go-discogs/model"
+	"github.com/state303/go-discogs/src/database"
 	"github.com/state303/go-discogs/src/helper"
 	"gorm.io/gorm/clause"
 )
@@ -25,10 +26,10 @@ const (
 	status            = "status"
 )
 
-var (
-	styleConstraint = clause.OnConflict{Columns: getClauseColumns([]string{id, name}), OnConstraint: "style_name_key", DoNothing: true}
-	genreConstraint = clause.OnConflict{Columns: getClauseColumns([]string{id, name}), OnConstraint: "genre_name_key", DoNothing: true}
-)
+// onNameConflict skips rows of which name exists, by the unique constraint named by postgres after the table.
+func onNameConflict(table string) clause.OnConflict {
+	return clause.OnConflict{Columns: getClauseColumns([]string{id, name}), OnConstraint: database.TableName(table) + "_name_key", DoNothing: true}
+}
 
 func ExtractClause(i interface{}) clause.OnConflict {
 	switch i.(type) {
@@ -41,9 +42,9 @@ func ExtractClause(i interface{}) clause.OnConflict {
 	case *model.Release:
 		return updateOnIdConflict(title, country, dataQuality, releasedYear, releasedMonth, releasedDay, listedReleaseDate, isMaster, masterId, notes, status)
 	case *model.Style:
-		return styleConstraint
+		return onNameConflict(model.TableNameStyle)
 	case *model.Genre:
-		return genreConstraint
+		return onNameConflict(model.TableNameGenre)
 	case *model.Data:
 		return clause.OnConflict{DoNothing: true}
 	}
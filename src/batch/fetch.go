@@ -0,0 +1,96 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"github.com/knadh/koanf"
+	"github.com/sirupsen/logrus"
+	"github.com/state303/go-discogs/src/data"
+	"github.com/state303/go-discogs/src/database"
+	"github.com/state303/go-discogs/src/file"
+	"github.com/state303/go-discogs/src/helper"
+	"github.com/state303/go-discogs/src/logging"
+	"time"
+)
+
+// FetchRunner downloads and verifies dump files of configured year, month and types, without loading them.
+// Dump data is looked up from the source listing, hence no database is required.
+type FetchRunner struct{}
+
+func (*FetchRunner) Run(ctx context.Context, config *koanf.Koanf) error {
+	stopMetrics, err := serveMetrics(config)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	src, err := newSource(config)
+	if err != nil {
+		return err
+	}
+
+	repo := data.NewMemoryRepository()
+	if _, err = data.UpdateData(ctx, src, repo); err != nil {
+		return err
+	}
+	if err = resolveGeneration(config, repo); err != nil {
+		return err
+	}
+
+	typeResourceMap, err := data.FetchFiles(ctx, config, src, repo)
+	if err != nil {
+		return err
+	}
+	for _, typ := range config.Strings("types") {
+		logrus.WithFields(logrus.Fields{logging.FieldType: typ, logging.FieldFile: typeResourceMap[typ]}).Info("fetched")
+	}
+	return nil
+}
+
+// VerifyRunner checks checksum of local dump files, then reports status of each file.
+type VerifyRunner struct{}
+
+// Run verifies given files or directories. Checksum is read from the CHECKSUM file next to dump files,
+// or from the data repository if dsn is configured.
+func (*VerifyRunner) Run(ctx context.Context, config *koanf.Koanf, paths []string) error {
+	var repo data.Repository
+	if dsn := config.String("dsn"); len(dsn) > 0 {
+		if err := connect(config); err != nil {
+			return err
+		}
+		repo = data.NewDataRepository(database.DB)
+	}
+
+	res, err := data.VerifyFiles(ctx, paths, file.NewHandler(), repo)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, v := range res {
+		s := fmt.Sprintf("%-8v %+v", v.Status(), v.Path)
+		if v.Err != nil {
+			failed++
+			s += fmt.Sprintf(" [error: %+v]", v.Err)
+		}
+		fmt.Println(s)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%+v of %+v files failed verification", failed, len(res))
+	}
+	return nil
+}
+
+// newSource returns data.Source of configured source, with download options.
+func newSource(config *koanf.Koanf) (data.Source, error) {
+	maxBandwidth, err := helper.ParseByteSize(config.String("max-bandwidth"))
+	if err != nil {
+		return nil, err
+	}
+	handler := file.NewHandlerWithOptions(file.Options{
+		Retries:      config.Int("retries"),
+		RetryBackoff: time.Second,
+		MaxBandwidth: maxBandwidth,
+	})
+	return data.NewSource(config.String("source"), handler), nil
+}
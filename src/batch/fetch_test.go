@@ -0,0 +1,64 @@
+package batch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/state303/go-discogs/internal/testserver"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestFetchRunner(t *testing.T) {
+	content := "dump content"
+	sum := sha256.Sum256([]byte(content))
+	server := testserver.NewBucketServer(map[string]string{
+		"data/2024/discogs_20240301_labels.xml.gz": content,
+		"data/2024/discogs_20240301_CHECKSUM.txt":  hex.EncodeToString(sum[:]) + " discogs_20240301_labels.xml.gz\n",
+	}, 1000)
+	defer server.Close()
+
+	dir := t.TempDir()
+	k := koanf.New(".")
+	require.NoError(t, k.Load(rawbytes.Provider([]byte(`
+types:
+  - labels
+year: "2024"
+month: "03"
+data: `+dir+`
+source: `+server.URL+`
+`)), yaml.Parser()))
+
+	require.NoError(t, new(FetchRunner).Run(context.Background(), k))
+	b, err := os.ReadFile(path.Join(dir, "discogs_20240301_labels.xml.gz"))
+	require.NoError(t, err)
+	require.Equal(t, content, string(b))
+
+	t.Run("fetch latest generation", func(t *testing.T) {
+		latestDir := t.TempDir()
+		k := koanf.New(".")
+		require.NoError(t, k.Load(rawbytes.Provider([]byte(`
+types:
+  - labels
+year: latest
+data: `+latestDir+`
+source: `+server.URL+`
+`)), yaml.Parser()))
+		require.NoError(t, new(FetchRunner).Run(context.Background(), k))
+		require.Equal(t, "2024", k.String("year"))
+		require.Equal(t, "03", k.String("month"))
+		require.FileExists(t, path.Join(latestDir, "discogs_20240301_labels.xml.gz"))
+	})
+
+	t.Run("verify reports fetched files", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path.Join(dir, "discogs_20240301_CHECKSUM.txt"), []byte(hex.EncodeToString(sum[:])+" discogs_20240301_labels.xml.gz\n"), 0644))
+		require.NoError(t, new(VerifyRunner).Run(context.Background(), koanf.New("."), []string{dir}))
+		require.NoError(t, os.WriteFile(path.Join(dir, "discogs_20240301_labels.xml.gz"), []byte("tampered"), 0644))
+		require.ErrorContains(t, new(VerifyRunner).Run(context.Background(), koanf.New("."), []string{dir}), "failed verification")
+	})
+}
@@ -0,0 +1,260 @@
+package batch
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/state303/go-discogs/model"
+	"github.com/state303/go-discogs/src/logging"
+	"github.com/state303/go-discogs/src/metrics"
+	"github.com/state303/go-discogs/src/result"
+	"github.com/state303/go-discogs/src/tracing"
+	"github.com/state303/go-discogs/src/unique"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// gormSink writes rows into the database, passing rows rejected by the database to ErrorHandler.
+type gormSink struct {
+	db        *gorm.DB
+	handler   ErrorHandler
+	chunkSize int
+}
+
+// NewGormSink returns Sink writing into db in batches of chunkSize rows, passing rows rejected by the database to handler.
+func NewGormSink(db *gorm.DB, handler ErrorHandler, chunkSize int) Sink {
+	return &gormSink{db: db, handler: handler, chunkSize: chunkSize}
+}
+
+func (g *gormSink) Upsert(ctx context.Context, entities ...interface{}) result.Result {
+	return g.write(ctx, entities...)
+}
+
+func (g *gormSink) Link(ctx context.Context, links ...interface{}) result.Result {
+	return g.write(ctx, links...)
+}
+
+func (g *gormSink) UpdateLabelParents(ctx context.Context, labels []*model.Label) result.Result {
+	if len(labels) == 0 {
+		return result.NewResult(0, nil)
+	}
+	tx := g.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"parent_id"}),
+	}).CreateInBatches(&labels, len(labels))
+	return result.NewResult(int(tx.RowsAffected), tx.Error)
+}
+
+func (g *gormSink) ResolveGenres(ctx context.Context, names []string) (map[string]int32, error) {
+	rows := make([]*model.Genre, len(names))
+	for i, name := range names {
+		rows[i] = &model.Genre{Name: name}
+	}
+	return resolveNames(g.db.WithContext(ctx), g.handler, rows, func(row *model.Genre) (string, int32) { return row.Name, row.ID })
+}
+
+func (g *gormSink) ResolveStyles(ctx context.Context, names []string) (map[string]int32, error) {
+	rows := make([]*model.Style, len(names))
+	for i, name := range names {
+		rows[i] = &model.Style{Name: name}
+	}
+	return resolveNames(g.db.WithContext(ctx), g.handler, rows, func(row *model.Style) (string, int32) { return row.Name, row.ID })
+}
+
+// resolveNames inserts rows of which name is missing, then returns ids of rows by name, as given by key.
+func resolveNames[T any](db *gorm.DB, handler ErrorHandler, rows []*T, key func(*T) (string, int32)) (map[string]int32, error) {
+	ids := make(map[string]int32, len(rows))
+	if len(rows) == 0 {
+		return ids, nil
+	}
+	if r := createRows(db, ExtractClause(rows[0]), rows, handler); r.IsErr() {
+		return nil, r.Err()
+	}
+	names := make([]string, len(rows))
+	for i, row := range rows {
+		names[i], _ = key(row)
+	}
+	var found []*T
+	if err := db.Where("name IN ?", names).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range found {
+		name, id := key(row)
+		ids[name] = id
+	}
+	return ids, nil
+}
+
+// Chunk runs write in a single transaction, so that a failure leaves none of its rows.
+// Rows skipped by ErrorHandler are rolled back to their savepoints only.
+func (g *gormSink) Chunk(ctx context.Context, write func(s Sink) result.Result) result.Result {
+	var res result.Result
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res = write(&gormSink{db: tx, handler: g.handler, chunkSize: g.chunkSize})
+		return res.Err()
+	})
+	if err != nil {
+		return result.NewResult(0, err)
+	}
+	return res
+}
+
+// write writes each slice of rows in order, stopping at the first error.
+func (g *gormSink) write(ctx context.Context, slices ...interface{}) result.Result {
+	var (
+		updated = 0
+		err     error
+		db      = g.db.WithContext(ctx)
+	)
+	for _, slice := range slices {
+		if err != nil {
+			break
+		}
+		var r result.Result
+		switch o := slice.(type) {
+		case []*model.Artist:
+			r = doWrite[*model.Artist](o, g.chunkSize, db, g.handler)
+		case []*model.ArtistURL:
+			r = doWrite[*model.ArtistURL](o, g.chunkSize, db, g.handler)
+		case []*model.ArtistAlias:
+			r = doWrite[*model.ArtistAlias](o, g.chunkSize, db, g.handler)
+		case []*model.ArtistGroup:
+			r = doWrite[*model.ArtistGroup](o, g.chunkSize, db, g.handler)
+		case []*model.ArtistNameVariation:
+			r = doWrite[*model.ArtistNameVariation](o, g.chunkSize, db, g.handler)
+		case []*model.Label:
+			r = doWrite[*model.Label](o, g.chunkSize, db, g.handler)
+		case []*model.LabelURL:
+			r = doWrite[*model.LabelURL](o, g.chunkSize, db, g.handler)
+		case []*model.LabelRelease:
+			r = doWrite[*model.LabelRelease](o, g.chunkSize, db, g.handler)
+		case []*model.Master:
+			r = doWrite[*model.Master](o, g.chunkSize, db, g.handler)
+		case []*model.MasterArtist:
+			r = doWrite[*model.MasterArtist](o, g.chunkSize, db, g.handler)
+		case []*model.MasterGenre:
+			r = doWrite[*model.MasterGenre](o, g.chunkSize, db, g.handler)
+		case []*model.MasterStyle:
+			r = doWrite[*model.MasterStyle](o, g.chunkSize, db, g.handler)
+		case []*model.MasterVideo:
+			r = doWrite[*model.MasterVideo](o, g.chunkSize, db, g.handler)
+		case []*model.Release:
+			r = doWrite[*model.Release](o, g.chunkSize, db, g.handler)
+		case []*model.ReleaseArtist:
+			r = doWrite[*model.ReleaseArtist](o, g.chunkSize, db, g.handler)
+		case []*model.ReleaseContract:
+			r = doWrite[*model.ReleaseContract](o, g.chunkSize, db, g.handler)
+		case []*model.ReleaseFormat:
+			r = doWrite[*model.ReleaseFormat](o, g.chunkSize, db, g.handler)
+		case []*model.ReleaseCreditedArtist:
+			r = doWrite[*model.ReleaseCreditedArtist](o, g.chunkSize, db, g.handler)
+		case []*model.ReleaseGenre:
+			r = doWrite[*model.ReleaseGenre](o, g.chunkSize, db, g.handler)
+		case []*model.ReleaseStyle:
+			r = doWrite[*model.ReleaseStyle](o, g.chunkSize, db, g.handler)
+		case []*model.ReleaseIdentifier:
+			r = doWrite[*model.ReleaseIdentifier](o, g.chunkSize, db, g.handler)
+		case []*model.ReleaseImage:
+			r = doWrite[*model.ReleaseImage](o, g.chunkSize, db, g.handler)
+		case []*model.ReleaseTrack:
+			r = doWrite[*model.ReleaseTrack](o, g.chunkSize, db, g.handler)
+		case []*model.ReleaseVideo:
+			r = doWrite[*model.ReleaseVideo](o, g.chunkSize, db, g.handler)
+		case []*model.Style:
+			r = doWrite[*model.Style](o, g.chunkSize, db, g.handler)
+		case []*model.Genre:
+			r = doWrite[*model.Genre](o, g.chunkSize, db, g.handler)
+		}
+		if r != nil {
+			updated += r.Count()
+			err = r.Err()
+		}
+	}
+
+	return result.NewResult(updated, err)
+}
+
+// doWrite writes items in chunks of chunkSize under a span of the table, a child of the span in context of db.
+func doWrite[T model.Keyer](items []T, chunkSize int, db *gorm.DB, handler ErrorHandler) (resultSum result.Result) {
+	var (
+		start = 0
+		end   = chunkSize
+		size  = len(items)
+	)
+	resultSum = result.NewResult(0, nil)
+	if size == 0 {
+		return
+	}
+	cl := ExtractClause(items[0])
+	table := getTableName(items[0])
+	ctx, span := tracing.Start(db.Statement.Context, "write", tracing.KeyTable.String(table))
+	defer func() { tracing.End(span, resultSum.Count(), resultSum.Err()) }()
+	db = db.WithContext(ctx)
+	for {
+		if start >= size || resultSum.IsErr() {
+			return resultSum
+		}
+		if end > size {
+			end = size
+		}
+		part := unique.Slice(items[start:end])
+		begin := time.Now()
+		r := createRows(db, cl, part, handler)
+		metrics.ChunkWriteSeconds.WithLabelValues(table).Observe(time.Since(begin).Seconds())
+		metrics.RecordsWritten.WithLabelValues(table).Add(float64(r.Count()))
+		log := logrus.WithFields(logrus.Fields{
+			logging.FieldType:    table,
+			logging.FieldChunk:   start / chunkSize,
+			logging.FieldCount:   r.Count(),
+			logging.FieldElapsed: logging.Elapsed(begin),
+		})
+		if r.IsErr() {
+			log.WithError(r.Err()).Error("failed to write chunk")
+		} else {
+			log.Debug("chunk written")
+		}
+		resultSum = resultSum.Sum(r)
+		start += chunkSize
+		end += chunkSize
+	}
+}
+
+// getTableName returns table of the row without prefix, or its type name if not a gorm model.
+func getTableName(row interface{}) string {
+	if t, ok := row.(interface{ TableName() string }); ok {
+		return t.TableName()
+	}
+	return getTypeName(row)
+}
+
+// createRows inserts rows in a single batch. If the batch fails under a policy other than PolicyFail,
+// rows are inserted one by one so that only rows rejected by the database are passed to handler.
+// Each insertion runs in its own transaction, or a savepoint if db is already in a transaction.
+func createRows[T any](db *gorm.DB, cl clause.OnConflict, rows []T, handler ErrorHandler) result.Result {
+	if len(rows) == 0 {
+		return result.NewResult(0, nil)
+	}
+	var affected int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(cl).CreateInBatches(&rows, len(rows))
+		affected = res.RowsAffected
+		return res.Error
+	})
+	if err == nil || handler.Policy() == PolicyFail {
+		return result.NewResult(int(affected), err)
+	}
+	count := 0
+	for _, row := range rows {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Clauses(cl).Create(row)
+			affected = res.RowsAffected
+			return res.Error
+		})
+		if err == nil {
+			count += int(affected)
+		} else if err = handler.HandleRow(row, err); err != nil {
+			return result.NewResult(count, err)
+		}
+	}
+	return result.NewResult(count, nil)
+}
@@ -0,0 +1,238 @@
+package batch
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/state303/go-discogs/model"
+	"github.com/state303/go-discogs/src/cache"
+	"github.com/state303/go-discogs/src/graph"
+	"github.com/state303/go-discogs/src/logging"
+	"github.com/state303/go-discogs/src/reader"
+	"github.com/state303/go-discogs/src/result"
+	"strconv"
+)
+
+// GraphRunner exports dump files as nodes and relationships for neo4j-admin import.
+type GraphRunner struct{}
+
+// Run exports each dump file from typeResourceMap into outDir.
+// Types are exported in order of artists, labels, masters then releases, as relations refer to cached ids of former types.
+func (*GraphRunner) Run(ctx context.Context, outDir string, typeResourceMap map[string]string) error {
+	w, err := graph.NewCSVWriter(outDir)
+	if err != nil {
+		return err
+	}
+
+	steps := make([]Step, 0)
+	if p, ok := typeResourceMap["artists"]; ok {
+		steps = append(steps, GetArtistGraphStep(NewOrder(ctx, 0, p, nil), w))
+	}
+	if p, ok := typeResourceMap["labels"]; ok {
+		steps = append(steps, GetLabelGraphStep(NewOrder(ctx, 0, p, nil), w))
+	}
+	if p, ok := typeResourceMap["masters"]; ok {
+		steps = append(steps, GetMasterGraphStep(NewOrder(ctx, 0, p, nil), w))
+	}
+	if p, ok := typeResourceMap["releases"]; ok {
+		steps = append(steps, GetReleaseGraphStep(NewOrder(ctx, 0, p, nil), w))
+	}
+
+	total, err := runSteps(ctx, steps, func(int, int, error) {})
+	if closeErr := w.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	logrus.WithFields(logrus.Fields{logging.FieldCount: total, "dir": outDir}).Info("exported graph records")
+	return err
+}
+
+// GetArtistGraphStep exports artist nodes, then their ALIAS_OF and MEMBER_OF relationships.
+func GetArtistGraphStep(order Order, w graph.Writer) Step {
+	return func() result.Result {
+		res := exportEach[XmlArtist](order, "artist", "exporting artists...", func(a *XmlArtist) (int, error) {
+			cache.ArtistIDCache.Store(a.ID, struct{}{})
+			return 1, w.WriteNode(graph.Artist, itoa(a.ID), str(a.Name), str(a.RealName), str(a.Profile), str(a.DataQuality))
+		})
+		if res.IsErr() {
+			return res
+		}
+		next := exportEach[XmlArtistRelation](order, "artist", "exporting artist relations...", func(a *XmlArtistRelation) (int, error) {
+			count := 0
+			for _, alias := range a.GetAliases() {
+				if err := w.WriteRelation(graph.AliasOf, itoa(alias.AliasID), itoa(alias.ArtistID)); err != nil {
+					return count, err
+				}
+				count++
+			}
+			for _, group := range a.GetGroups() {
+				if err := w.WriteRelation(graph.MemberOf, itoa(group.ArtistID), itoa(group.GroupID)); err != nil {
+					return count, err
+				}
+				count++
+			}
+			return count, nil
+		})
+		return result.NewResult(res.Count()+next.Count(), next.Err())
+	}
+}
+
+// GetLabelGraphStep exports label nodes, then their SUBLABEL_OF relationships.
+func GetLabelGraphStep(order Order, w graph.Writer) Step {
+	return func() result.Result {
+		res := exportEach[XmlLabel](order, "label", "exporting labels...", func(l *XmlLabel) (int, error) {
+			cache.LabelIDCache.Store(l.ID, struct{}{})
+			return 1, w.WriteNode(graph.Label, itoa(l.ID), str(l.Name), str(l.ContactInfo), str(l.Profile), str(l.DataQuality))
+		})
+		if res.IsErr() {
+			return res
+		}
+		next := exportEach[XmlLabelRelation](order, "label", "exporting label relations...", func(l *XmlLabelRelation) (int, error) {
+			pid := l.GetParentID()
+			if pid == nil {
+				return 0, nil
+			}
+			if _, ok := cache.LabelIDCache.Load(*pid); !ok {
+				return 0, nil
+			}
+			return 1, w.WriteRelation(graph.SublabelOf, itoa(l.ID), itoa(*pid))
+		})
+		return result.NewResult(res.Count()+next.Count(), next.Err())
+	}
+}
+
+// GetMasterGraphStep exports master nodes with their artists, genres and styles.
+func GetMasterGraphStep(order Order, w graph.Writer) Step {
+	return func() result.Result {
+		return exportEach[XmlMasterRelation](order, "master", "exporting masters...", func(mr *XmlMasterRelation) (int, error) {
+			m := mr.GetMaster()
+			cache.MasterIDCache.Store(m.ID, struct{}{})
+			if err := w.WriteNode(graph.Master, itoa(m.ID), str(m.Title), num(m.ReleasedYear), str(m.DataQuality)); err != nil {
+				return 0, err
+			}
+			count := 1
+			for _, ma := range mr.GetMasterArtists() {
+				if err := w.WriteRelation(graph.MasterArtist, itoa(ma.ArtistID), itoa(m.ID)); err != nil {
+					return count, err
+				}
+				count++
+			}
+			n, err := writeGenreStyles(w, itoa(m.ID), graph.MasterGenre, graph.MasterStyle, mr.GetGenres(), mr.GetStyles())
+			return count + n, err
+		})
+	}
+}
+
+// GetReleaseGraphStep exports release nodes with their artists, credits, labels, master, genres and styles.
+func GetReleaseGraphStep(order Order, w graph.Writer) Step {
+	return func() result.Result {
+		return exportEach[XmlReleaseRelation](order, "release", "exporting releases...", func(rr *XmlReleaseRelation) (int, error) {
+			r := rr.GetRelease()
+			rid := itoa(r.ID)
+			if err := w.WriteNode(graph.Release, rid, str(r.Title), str(r.Country), str(r.ListedReleaseDate), num(r.ReleasedYear), str(r.Status), str(r.DataQuality)); err != nil {
+				return 0, err
+			}
+			count := 1
+			write := func(rel graph.Relation, start, end string, props ...string) error {
+				if err := w.WriteRelation(rel, start, end, props...); err != nil {
+					return err
+				}
+				count++
+				return nil
+			}
+			if r.MasterID != nil {
+				if err := write(graph.VersionOf, rid, itoa(*r.MasterID)); err != nil {
+					return count, err
+				}
+			}
+			for _, ra := range rr.GetReleaseArtists() {
+				if err := write(graph.ReleaseArtist, itoa(ra.ArtistID), rid); err != nil {
+					return count, err
+				}
+			}
+			for _, ca := range rr.GetCreditedArtists() {
+				if err := write(graph.CreditedOn, itoa(ca.ArtistID), rid, str(ca.Role)); err != nil {
+					return count, err
+				}
+			}
+			for _, lr := range rr.GetLabels() {
+				if err := write(graph.ReleasedOn, rid, itoa(lr.LabelID), str(lr.CategoryNotation)); err != nil {
+					return count, err
+				}
+			}
+			n, err := writeGenreStyles(w, rid, graph.ReleaseGenre, graph.ReleaseStyle, rr.GetGenres(), rr.GetStyles())
+			return count + n, err
+		})
+	}
+}
+
+func writeGenreStyles(w graph.Writer, id string, genreRel, styleRel graph.Relation, genres []*model.Genre, styles []*model.Style) (int, error) {
+	count := 0
+	for _, g := range genres {
+		if err := w.WriteNode(graph.Genre, g.Name); err != nil {
+			return count, err
+		}
+		if err := w.WriteRelation(genreRel, id, g.Name); err != nil {
+			return count, err
+		}
+		count++
+	}
+	for _, s := range styles {
+		if err := w.WriteNode(graph.Style, s.Name); err != nil {
+			return count, err
+		}
+		if err := w.WriteRelation(styleRel, id, s.Name); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// exportEach reads every item of types T from the order file then calls export on it.
+// It stops at the first error, either from reading or export.
+func exportEach[T any](order Order, localName string, progressBarText string, export func(*T) (int, error)) result.Result {
+	r, err := newReadCloser(order.getFilePath(), order.getGzip(), progressBarText)
+	if err != nil {
+		return result.NewResult(0, err)
+	}
+	ctx, cancel := context.WithCancel(order.getContext())
+	defer cancel()
+
+	sum := 0
+	for item := range reader.NewReader[T](ctx, r, localName).Observe() {
+		if item.E != nil {
+			return result.NewResult(sum, item.E)
+		}
+		if item.V == nil {
+			continue
+		}
+		n, err := export(item.V.(*T))
+		sum += n
+		if err != nil {
+			return result.NewResult(sum, err)
+		}
+	}
+	logrus.WithFields(logrus.Fields{
+		logging.FieldType:  localName,
+		logging.FieldFile:  reader.GetFilename(order.getFilePath()),
+		logging.FieldCount: sum,
+	}).Info("exported")
+	return result.NewResult(sum, nil)
+}
+
+func itoa(i int32) string {
+	return strconv.Itoa(int(i))
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func num(n *int16) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(int(*n))
+}
@@ -0,0 +1,38 @@
+package batch
+
+import (
+	"context"
+	"encoding/csv"
+	"github.com/state303/go-discogs/src/graph"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path"
+	"testing"
+)
+
+func countCsvRecords(t *testing.T, filepath string) int {
+	f, err := os.Open(filepath)
+	require.NoError(t, err)
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	return len(records) - 1 // without header
+}
+
+func TestGraphRunner(t *testing.T) {
+	dir := t.TempDir()
+	err := new(GraphRunner).Run(context.Background(), dir, map[string]string{
+		"artists":  "testdata/artist.xml.gz",
+		"labels":   "testdata/label.xml.gz",
+		"masters":  "testdata/master.xml.gz",
+		"releases": "testdata/release.xml.gz",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, 3, countCsvRecords(t, path.Join(dir, graph.NodeFilename(graph.Artist))))
+	require.Equal(t, 5, countCsvRecords(t, path.Join(dir, graph.NodeFilename(graph.Label))))
+	require.Equal(t, 3, countCsvRecords(t, path.Join(dir, graph.NodeFilename(graph.Master))))
+	require.Equal(t, 3, countCsvRecords(t, path.Join(dir, graph.NodeFilename(graph.Release))))
+	require.NotZero(t, countCsvRecords(t, path.Join(dir, graph.RelationFilename(graph.AliasOf))))
+	require.NotZero(t, countCsvRecords(t, path.Join(dir, graph.RelationFilename(graph.ReleaseGenre))))
+}
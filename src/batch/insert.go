@@ -2,37 +2,51 @@ package batch
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/reactivex/rxgo/v2"
+	"github.com/sirupsen/logrus"
 	"github.com/state303/go-discogs/model"
 	"github.com/state303/go-discogs/src/cache"
-	"github.com/state303/go-discogs/src/helper"
+	"github.com/state303/go-discogs/src/logging"
+	"github.com/state303/go-discogs/src/pipeline"
 	"github.com/state303/go-discogs/src/reader"
 	"github.com/state303/go-discogs/src/result"
+	"github.com/state303/go-discogs/src/xmlparser"
+	"gorm.io/gorm"
+	"io"
+	"sync"
+	"time"
 )
 
+// InsertSimple upserts models transformed from elements of localName, caching ids of them.
 func InsertSimple[F, T any](order Order, topic string, localName string) result.Result {
-	r := newReadCloser(order.getFilePath(), fmt.Sprintf("updating %+v...", topic))
-	res := <-reader.NewReader[F](order.getContext(), r, localName).
-		FlatMap(Transform).
-		Map(registerCache).
-		WindowWithCount(order.getChunkSize()).
-		Map(helper.MapWindowedSlice[*T]()).
-		Map(insertBySlice[*T](order)).
-		Reduce(helper.MergeCount()).
-		Observe(rxgo.WithCPUPool())
-	if res.E != nil {
-		return result.NewResult(0, res.E)
-	} else {
-		fmt.Printf("\nUpdated %+v %+v\n", res.V.(int), topic)
-		return result.NewResult(res.V.(int), nil)
+	begin := time.Now()
+	r, err := newReadCloser(order.getFilePath(), order.getGzip(), fmt.Sprintf("updating %+v...", topic))
+	if err != nil {
+		return result.NewResult(0, err)
 	}
+	res := runPipeline(order, readElements[F](order, r, localName, topic), transformChunk[F, T])
+	if res.IsErr() {
+		return result.NewResult(0, res.Err())
+	}
+	logUpdated(topic, res.Count(), begin)
+	return res
 }
 
-func registerCache(_ context.Context, i interface{}) (interface{}, error) {
-	if i == nil {
-		return i, nil
+// transformChunk returns chunkWriter upserting models of items, caching ids of them.
+func transformChunk[F, T any](items []*F) (chunkWriter, error) {
+	rows := make([]*T, 0, len(items))
+	for _, item := range items {
+		v := Transform(item)
+		registerCache(v)
+		if row, ok := v.(*T); ok {
+			rows = append(rows, row)
+		}
 	}
+	return upsertThenLink(rows), nil
+}
+
+func registerCache(i interface{}) {
 	switch o := i.(type) {
 	case *model.Artist:
 		cache.ArtistIDCache.Store(o.ID, struct{}{})
@@ -41,5 +55,56 @@ func registerCache(_ context.Context, i interface{}) (interface{}, error) {
 	case *model.Master:
 		cache.MasterIDCache.Store(o.ID, struct{}{})
 	}
-	return i, nil
+}
+
+// warmIDCache caches ids of every row of model m already in db into c, as registerCache does while the step of m
+// runs. Returns number of ids cached.
+func warmIDCache(db *gorm.DB, m interface{}, c *sync.Map) (int, error) {
+	rows, err := db.Session(&gorm.Session{}).Model(m).Select("id").Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rows.Close() }()
+	count := 0
+	for rows.Next() {
+		var id int32
+		if err = rows.Scan(&id); err != nil {
+			return count, err
+		}
+		c.Store(id, struct{}{})
+		count++
+	}
+	return count, rows.Err()
+}
+
+// logUpdated logs count of records updated by a step of topic since begin.
+func logUpdated(topic string, count int, begin time.Time) {
+	logrus.WithFields(logrus.Fields{
+		logging.FieldType:    topic,
+		logging.FieldCount:   count,
+		logging.FieldElapsed: logging.Elapsed(begin),
+	}).Info("updated")
+}
+
+// readElements returns pipeline.Source of elements of localName read from r in order, decoding them on workers of
+// the order. ErrorHandler of the order is applied on malformed elements.
+// Readers stop silently on cancellation, as the step regards it as failure.
+func readElements[T any](order Order, r io.ReadCloser, localName, topic string) pipeline.Source[*T] {
+	opts := xmlparser.ParallelOptions{Workers: order.getPipelineOptions().Workers, Ordered: true}
+	onElementError := func(err *xmlparser.ElementError) error {
+		return order.getErrorHandler().HandleElement(topic, err)
+	}
+	return func(ctx context.Context, out chan<- *T) error {
+		err := reader.ReadParallel[T](ctx, r, localName, opts, onElementError, out)
+		if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			return nil
+		}
+		return err
+	}
+}
+
+// runPipeline writes every chunk of items read from src, prepared into chunkWriter by prepare.
+// Chunks are prepared and written concurrently as configured by order, each in a Sink chunk of its own.
+func runPipeline[T any](order Order, src pipeline.Source[*T], prepare func(items []*T) (chunkWriter, error)) result.Result {
+	return pipeline.Run(order.getContext(), src, prepare, writeChunk(order), order.getPipelineOptions())
 }
@@ -1,16 +1,12 @@
 package batch
 
 import (
-	"fmt"
+	"context"
 	"github.com/sirupsen/logrus"
 	"github.com/state303/go-discogs/model"
 	"github.com/state303/go-discogs/src/cache"
-	"github.com/state303/go-discogs/src/helper"
-	"github.com/state303/go-discogs/src/reader"
 	"github.com/state303/go-discogs/src/result"
-	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
-	"sync"
+	"time"
 )
 
 //TODO: add label release step for future use
@@ -37,60 +33,34 @@ func insertLabels(order Order) result.Result {
 }
 
 func insertLabelRelations(order Order) result.Result {
-	r := newReadCloser(order.getFilePath(), "updating label relations...")
-
-	var (
-		wg   = new(sync.WaitGroup)
-		res  = make(chan result.Result)
-		done = make(chan struct{}, 1)
-	)
-
-	go func() {
-		<-reader.NewReader[XmlLabelRelation](order.getContext(), r, "label").
-			WindowWithCount(order.getChunkSize()).
-			Map(helper.MapWindowedSlice[*XmlLabelRelation]()).
-			ForEach(
-				writeLabelRelations(order, res, wg), // DoOnNext
-				printError(),                        // DoOnError
-				signalDone(done, wg))                //DoOnComplete
-	}()
-
-	go func() { // wait until done called then close res chan
-		<-done
-		close(res)
-	}()
-
-	sum := result.NewResult(0, nil)
-	for next := range res {
-		sum = sum.Sum(next)
+	begin := time.Now()
+	r, err := newReadCloser(order.getFilePath(), order.getGzip(), "updating label relations...")
+	if err != nil {
+		return result.NewResult(0, err)
 	}
-
-	fmt.Printf("\nUpdated %+v label relations\n", sum.Count())
+	sum := runPipeline(order, readElements[XmlLabelRelation](order, r, "label", "label relations"), prepareLabelRelations)
+	logUpdated("label relations", sum.Count(), begin)
 	return sum
 }
 
-func signalDone(done chan<- struct{}, wg *sync.WaitGroup) func() {
-	return func() {
-		defer close(done)
-		wg.Wait()
-		done <- struct{}{}
+// prepareLabelRelations returns chunkWriter setting parents of labels, then writing their urls.
+func prepareLabelRelations(lrs []*XmlLabelRelation) (chunkWriter, error) {
+	u := make([]*model.LabelURL, 0)
+	for _, lr := range lrs {
+		u = append(u, lr.GetUrls()...)
 	}
-}
-
-func writeLabelRelations(order Order, res chan result.Result, wg *sync.WaitGroup) func(i interface{}) {
-	return func(i interface{}) {
-		wg.Add(2)
-		u := make([]*model.LabelURL, 0)
-		lrs := i.([]*XmlLabelRelation)
-		for _, lr := range lrs {
-			u = append(u, lr.GetUrls()...)
+	parents := getLabelParents(lrs)
+	return func(ctx context.Context, s Sink) result.Result {
+		r := s.UpdateLabelParents(ctx, parents)
+		if r.IsErr() {
+			return r
 		}
-		go func() { defer wg.Done(); res <- updateLabelsParent(lrs, order.getDB()) }()
-		go func() { defer wg.Done(); res <- writeThenReport(order, wg, u) }()
-	}
+		return r.Sum(s.Link(ctx, u))
+	}, nil
 }
 
-func updateLabelsParent(labels []*XmlLabelRelation, db *gorm.DB) result.Result {
+// getLabelParents returns labels of which parent is known, with their parents set.
+func getLabelParents(labels []*XmlLabelRelation) []*model.Label {
 	lps := make([]*model.Label, 0)
 	for _, v := range labels {
 		pid := v.GetParentID()
@@ -98,14 +68,9 @@ func updateLabelsParent(labels []*XmlLabelRelation, db *gorm.DB) result.Result {
 			continue
 		}
 		if _, ok := cache.LabelIDCache.Load(*pid); ok {
-			logrus.Debugf("\nlookup for lable id %+v failed due to missing cache\n", *pid)
+			logrus.WithField("parent_id", *pid).Debug("updating parent of label")
 			lps = append(lps, &model.Label{ID: v.ID, ParentID: pid})
 		}
 	}
-	tx := db.Session(&gorm.Session{}).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"parent_id"}),
-	}).CreateInBatches(&lps, len(lps))
-
-	return result.NewResult(int(tx.RowsAffected), tx.Error)
+	return lps
 }
@@ -0,0 +1,91 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/knadh/koanf"
+	"github.com/state303/go-discogs/src/data"
+	"github.com/state303/go-discogs/src/database"
+	"github.com/state303/go-discogs/src/helper"
+	"io"
+	"os"
+	"path"
+	"text/tabwriter"
+	"time"
+)
+
+// CatalogueEntry is a dump data with its local and load status.
+type CatalogueEntry struct {
+	GeneratedAt string     `json:"generated_at"`
+	Type        string     `json:"type"`
+	Size        int64      `json:"size"`
+	Checksum    string     `json:"checksum"`
+	Downloaded  bool       `json:"downloaded"`
+	Loaded      bool       `json:"loaded"`
+	LoadedAt    *time.Time `json:"loaded_at,omitempty"`
+	Uri         string     `json:"uri"`
+}
+
+// ListRunner prints the dump catalogue from data repository.
+type ListRunner struct{}
+
+func (*ListRunner) Run(config *koanf.Koanf, w io.Writer) error {
+	if err := connect(config); err != nil {
+		return err
+	}
+	if err := data.Migrate(database.DB); err != nil {
+		return err
+	}
+	return PrintCatalogue(config, data.NewDataRepository(database.DB), w)
+}
+
+// PrintCatalogue writes items of the repository filtered by year, types and latest, either as a table or json.
+func PrintCatalogue(config *koanf.Koanf, repo data.Repository, w io.Writer) error {
+	items, err := repo.FindAll(data.Filter{
+		Year:   config.String("year"),
+		Types:  config.Strings("types"),
+		Latest: config.Bool("latest"),
+	})
+	if err != nil {
+		return err
+	}
+
+	entries := make([]*CatalogueEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, &CatalogueEntry{
+			GeneratedAt: item.GeneratedAt.Format("2006-01-02"),
+			Type:        item.TargetType,
+			Size:        item.Size,
+			Checksum:    item.Checksum,
+			Downloaded:  isDownloaded(config.String("data"), item),
+			Loaded:      item.LoadedAt != nil,
+			LoadedAt:    item.LoadedAt,
+			Uri:         item.Uri,
+		})
+	}
+
+	if config.String("output") == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "GENERATED\tTYPE\tSIZE\tCHECKSUM\tDOWNLOADED\tLOADED")
+	for _, e := range entries {
+		loaded := "-"
+		if e.LoadedAt != nil {
+			loaded = e.LoadedAt.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(tw, "%+v\t%+v\t%+v\t%+v\t%+v\t%+v\n", e.GeneratedAt, e.Type, e.Size, e.Checksum, e.Downloaded, loaded)
+	}
+	return tw.Flush()
+}
+
+func isDownloaded(dir string, d *data.Data) bool {
+	if len(dir) == 0 {
+		return false
+	}
+	_, err := os.Stat(path.Join(dir, helper.GetLastUriSegment(d.Uri)))
+	return err == nil
+}
@@ -0,0 +1,62 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/state303/go-discogs/src/data"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintCatalogue(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(dir, "discogs_20240301_artists.xml.gz"), []byte("x"), 0644))
+
+	repo := data.NewMemoryRepository()
+	loadedAt := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	_, _ = repo.BatchInsert([]*data.Data{
+		{ETag: "1", GeneratedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), TargetType: "artists", Uri: "data/2024/discogs_20240201_artists.xml.gz", Size: 10},
+		{ETag: "2", GeneratedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), TargetType: "artists", Uri: "data/2024/discogs_20240301_artists.xml.gz", Size: 20, Checksum: "abc"},
+		{ETag: "3", GeneratedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), TargetType: "labels", Uri: "data/2024/discogs_20240301_labels.xml.gz", Size: 5},
+		{ETag: "4", GeneratedAt: time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), TargetType: "labels", Uri: "data/2023/discogs_20231201_labels.xml.gz", Size: 5},
+	})
+	require.NoError(t, repo.MarkLoaded("2", loadedAt))
+
+	getConfig := func(t *testing.T, yml string) *koanf.Koanf {
+		k := koanf.New(".")
+		require.NoError(t, k.Load(rawbytes.Provider([]byte(yml+"\ndata: "+dir+"\n")), yaml.Parser()))
+		return k
+	}
+
+	t.Run("prints latest as json", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, PrintCatalogue(getConfig(t, "latest: true\noutput: json"), repo, buf))
+		var entries []*CatalogueEntry
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entries))
+		require.Len(t, entries, 2)
+		require.Equal(t, "2024-03-01", entries[0].GeneratedAt)
+		require.Equal(t, "artists", entries[0].Type)
+		require.True(t, entries[0].Downloaded)
+		require.True(t, entries[0].Loaded)
+		require.False(t, entries[1].Downloaded)
+		require.False(t, entries[1].Loaded)
+	})
+
+	t.Run("prints filtered table", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, PrintCatalogue(getConfig(t, "year: \"2024\"\ntypes:\n  - artists"), repo, buf))
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 3)
+		require.Contains(t, lines[0], "GENERATED")
+		require.Contains(t, lines[1], "2024-03-01")
+		require.Contains(t, lines[1], loadedAt.Format(time.RFC3339))
+		require.Contains(t, lines[2], "2024-02-01")
+	})
+}
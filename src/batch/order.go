@@ -2,6 +2,8 @@ package batch
 
 import (
 	"context"
+	"github.com/state303/go-discogs/src/decompress"
+	"github.com/state303/go-discogs/src/pipeline"
 	"gorm.io/gorm"
 )
 
@@ -9,14 +11,24 @@ type Order interface {
 	getContext() context.Context
 	getChunkSize() int
 	getFilePath() string
-	getDB() *gorm.DB
+	getSink() Sink
+	getErrorHandler() ErrorHandler
+	getPipelineOptions() pipeline.Options
+	getGzip() string
+	withContext(ctx context.Context) Order
+	withConcurrency(workers, writers int) Order
+	withGzip(gzipImpl string) Order
 }
 
 type orderImpl struct {
 	ctx       context.Context
 	chunkSize int
 	filepath  string
-	db        *gorm.DB
+	sink      Sink
+	handler   ErrorHandler
+	workers   int
+	writers   int
+	gzipImpl  string
 }
 
 func (o *orderImpl) getContext() context.Context {
@@ -31,15 +43,66 @@ func (o *orderImpl) getFilePath() string {
 	return o.filepath
 }
 
-func (o *orderImpl) getDB() *gorm.DB {
-	return o.db.Session(&gorm.Session{})
+func (o *orderImpl) getSink() Sink {
+	return o.sink
 }
 
+func (o *orderImpl) getErrorHandler() ErrorHandler {
+	return o.handler
+}
+
+// getPipelineOptions returns options of pipeline.Run, where zero workers and writers are defaults of pipeline.
+func (o *orderImpl) getPipelineOptions() pipeline.Options {
+	return pipeline.Options{ChunkSize: o.chunkSize, Workers: o.workers, Writers: o.writers}
+}
+
+// withConcurrency returns copy of the order preparing chunks on workers goroutines, writing up to writers chunks at once.
+func (o *orderImpl) withConcurrency(workers, writers int) Order {
+	c := *o
+	c.workers, c.writers = workers, writers
+	return &c
+}
+
+// getGzip returns gzip implementation to decompress the order file with, defaulting to decompress.GzipStandard.
+func (o *orderImpl) getGzip() string {
+	if len(o.gzipImpl) == 0 {
+		return decompress.GzipStandard
+	}
+	return o.gzipImpl
+}
+
+// withGzip returns copy of the order decompressing gzip compressed file with gzipImpl.
+func (o *orderImpl) withGzip(gzipImpl string) Order {
+	c := *o
+	c.gzipImpl = gzipImpl
+	return &c
+}
+
+// withContext returns copy of the order carrying ctx, such as one holding the span of its step.
+func (o *orderImpl) withContext(ctx context.Context) Order {
+	c := *o
+	c.ctx = ctx
+	return &c
+}
+
+// NewOrder returns Order that stops at the first error.
 func NewOrder(ctx context.Context, chunkSize int, filepath string, db *gorm.DB) Order {
+	return NewOrderWithErrorHandler(ctx, chunkSize, filepath, db, NewErrorHandler(PolicyFail, nil))
+}
+
+// NewOrderWithErrorHandler returns Order writing into db, that handles malformed elements and rejected rows
+// with given ErrorHandler.
+func NewOrderWithErrorHandler(ctx context.Context, chunkSize int, filepath string, db *gorm.DB, handler ErrorHandler) Order {
+	return NewOrderWithSink(ctx, chunkSize, filepath, NewGormSink(db, handler, chunkSize), handler)
+}
+
+// NewOrderWithSink returns Order writing into sink, that handles malformed elements with given ErrorHandler.
+func NewOrderWithSink(ctx context.Context, chunkSize int, filepath string, sink Sink, handler ErrorHandler) Order {
 	return &orderImpl{
 		ctx:       ctx,
 		chunkSize: chunkSize,
 		filepath:  filepath,
-		db:        db,
+		sink:      sink,
+		handler:   handler,
 	}
 }
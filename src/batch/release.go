@@ -1,16 +1,13 @@
 package batch
 
 import (
-	"fmt"
 	"github.com/state303/go-discogs/model"
 	"github.com/state303/go-discogs/src/cache"
-	"github.com/state303/go-discogs/src/helper"
-	"github.com/state303/go-discogs/src/reader"
 	"github.com/state303/go-discogs/src/result"
+	"github.com/state303/go-discogs/src/tracing"
 	"github.com/state303/go-discogs/src/unique"
-	"gorm.io/gorm/clause"
 	"strings"
-	"sync"
+	"time"
 )
 
 // TODO: double check ptr exceptions on reference
@@ -24,43 +21,19 @@ func GetReleaseStep(order Order) Step {
 }
 
 func insertReleases(order Order) result.Result {
-	r := newReadCloser(order.getFilePath(), "updating releases...")
-
-	var (
-		wg   = new(sync.WaitGroup)
-		res  = make(chan result.Result)
-		done = make(chan struct{}, 1)
-	)
-
-	go func() {
-		<-reader.NewReader[XmlReleaseRelation](order.getContext(), r, "release").
-			WindowWithCount(order.getChunkSize()).
-			Map(helper.MapWindowedSlice[*XmlReleaseRelation]()).
-			ForEach(
-				doInsertReleases(order, res, wg),
-				printError(),
-				signalDone(done, wg))
-	}()
-
-	go func() {
-		<-done
-		close(res)
-	}()
-
-	sum := result.NewResult(0, nil)
-	for next := range res {
-		sum = sum.Sum(next)
+	begin := time.Now()
+	r, err := newReadCloser(order.getFilePath(), order.getGzip(), "updating releases...")
+	if err != nil {
+		return result.NewResult(0, err)
 	}
-
-	fmt.Printf("\nUpdated %+v release relations\n", sum.Count())
+	sum := runPipeline(order, readElements[XmlReleaseRelation](order, r, "release", "releases"), prepareReleases(order))
+	logUpdated("release relations", sum.Count(), begin)
 	return sum
 }
 
-func doInsertReleases(order Order, res chan result.Result, wg *sync.WaitGroup) func(i interface{}) {
-	return func(i interface{}) {
-		wg.Add(1)
-		rrs := i.([]*XmlReleaseRelation)
-
+// prepareReleases returns func resolving genres and styles of releases, then returning chunkWriter of them.
+func prepareReleases(order Order) func(rrs []*XmlReleaseRelation) (chunkWriter, error) {
+	return func(rrs []*XmlReleaseRelation) (chunkWriter, error) {
 		var (
 			g   = make([]*model.Genre, 0)
 			s   = make([]*model.Style, 0)
@@ -78,37 +51,14 @@ func doInsertReleases(order Order, res chan result.Result, wg *sync.WaitGroup) f
 		)
 
 		for _, rr := range rrs {
-			if rr == nil {
-				continue
-			}
 			g = append(g, rr.GetGenres()...)
 			s = append(s, rr.GetStyles()...)
 		}
-
-		order.getDB().
-			Clauses(clause.OnConflict{DoNothing: true}).
-			CreateInBatches(filterGenres(g), order.getChunkSize())
-		order.getDB().
-			Clauses(clause.OnConflict{DoNothing: true}).
-			CreateInBatches(filterStyles(s), order.getChunkSize())
-
-		var fg []*model.Genre
-		var fs []*model.Style
-
-		order.getDB().Find(&fs)
-		order.getDB().Find(&fg)
-
-		for _, v := range fs {
-			cache.StyleCache.Store(v.Name, v.ID)
-		}
-		for _, v := range fg {
-			cache.GenreCache.Store(v.Name, v.ID)
+		if err := insertGenresStyles(order, g, s); err != nil {
+			return nil, err
 		}
 
 		for _, rr := range rrs {
-			if rr == nil {
-				continue
-			}
 			rel = append(rel, rr.GetRelease())
 			ra = append(ra, rr.GetReleaseArtists()...)
 			rg = append(rg, rr.GetReleaseGenres()...)
@@ -121,35 +71,53 @@ func doInsertReleases(order Order, res chan result.Result, wg *sync.WaitGroup) f
 			rv = append(rv, rr.GetVideos()...)
 			rca = append(rca, rr.GetCreditedArtists()...)
 		}
+		return upsertThenLink(rel, ra, rc, rs, rg, rl, rf, ri, rt, rv, rca), nil
+	}
+}
 
-		go func(res chan result.Result) {
-			defer wg.Done()
-			res <- writeThenReport(order, wg, rel, ra, rc, rs, rg, rl, rf, ri, rt, rv, rca)
-		}(res)
+// insertGenresStyles resolves ids of genres and styles missing from cache through the sink of order, then caches them.
+func insertGenresStyles(order Order, g []*model.Genre, s []*model.Style) error {
+	ctx := tracing.Detach(order.getContext())
+	genres, err := order.getSink().ResolveGenres(ctx, filterGenres(g))
+	if err != nil {
+		return err
+	}
+	styles, err := order.getSink().ResolveStyles(ctx, filterStyles(s))
+	if err != nil {
+		return err
+	}
+	for name, id := range genres {
+		cache.GenreCache.Store(name, id)
+	}
+	for name, id := range styles {
+		cache.StyleCache.Store(name, id)
 	}
+	return nil
 }
 
-func filterGenres(genres []*model.Genre) []*model.Genre {
-	r := make([]*model.Genre, 0)
+// filterGenres returns names of genres missing from cache.
+func filterGenres(genres []*model.Genre) []string {
+	r := make([]string, 0)
 	for _, v := range unique.Slice(genres) {
 		if name := strings.TrimSpace(v.Name); len(name) == 0 {
 			continue
 		}
-		if _, ok := cache.GenreCache.Load(v); !ok {
-			r = append(r, v)
+		if _, ok := cache.GenreCache.Load(v.Name); !ok {
+			r = append(r, v.Name)
 		}
 	}
 	return r
 }
 
-func filterStyles(styles []*model.Style) []*model.Style {
-	r := make([]*model.Style, 0)
+// filterStyles returns names of styles missing from cache.
+func filterStyles(styles []*model.Style) []string {
+	r := make([]string, 0)
 	for _, v := range unique.Slice(styles) {
 		if name := strings.TrimSpace(v.Name); len(name) == 0 {
 			continue
 		}
-		if _, ok := cache.StyleCache.Load(v); !ok {
-			r = append(r, v)
+		if _, ok := cache.StyleCache.Load(v.Name); !ok {
+			r = append(r, v.Name)
 		}
 	}
 	return r
@@ -1,16 +1,24 @@
 package batch
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"github.com/state303/go-discogs/src/decompress"
 	"github.com/state303/go-discogs/src/reader"
+	"github.com/state303/go-discogs/src/xmlparser"
 	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
 func TestReleaseRead(t *testing.T) {
+	r, err := newReadCloser("testdata/release.xml.gz", decompress.GzipStandard, "test-read-release")
+	require.NoError(t, err)
 	var (
 		c = context.Background()
-		r = newReadCloser("testdata/release.xml.gz", "test-read-release")
 		n = "release"
 	)
 	obs := reader.NewReader[XmlRelease](c, r, n)
@@ -33,9 +41,10 @@ func TestReleaseRead(t *testing.T) {
 }
 
 func TestReleaseRelationRead(t *testing.T) {
+	r, err := newReadCloser("testdata/release.xml.gz", decompress.GzipStandard, "test-read-release")
+	require.NoError(t, err)
 	var (
 		c = context.Background()
-		r = newReadCloser("testdata/release.xml.gz", "test-read-release")
 		n = "release"
 	)
 	obs := reader.NewReader[XmlReleaseRelation](c, r, n)
@@ -76,3 +85,106 @@ func TestReleaseRelationStrTim(t *testing.T) {
 	require.Nil(t, releaseObj.DataQuality)
 	require.Nil(t, releaseObj.Notes)
 }
+
+func TestNewReadCloserReturnsErr(t *testing.T) {
+	_, err := newReadCloser("testdata/missing.xml.gz", decompress.GzipStandard, "test-missing")
+	require.Error(t, err)
+	broken := filepath.Join(t.TempDir(), "broken.xml.gz")
+	require.NoError(t, os.WriteFile(broken, []byte{0x1f, 0x8b, 0x00}, 0644))
+	_, err = newReadCloser(broken, decompress.GzipStandard, "test-broken-gzip")
+	require.ErrorContains(t, err, "failed to read")
+}
+
+func TestNewReadCloserDetectsFormat(t *testing.T) {
+	read := func(path, gzipImpl string) []byte {
+		r, err := newReadCloser(path, gzipImpl, "test-detect-format")
+		require.NoError(t, err)
+		defer func() { require.NoError(t, r.Close()) }()
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return b
+	}
+	plain := read("testdata/release.xml", decompress.GzipStandard)
+	require.Equal(t, plain, read("testdata/release.xml.gz", decompress.GzipStandard))
+	require.Equal(t, plain, read("testdata/release.xml.gz", decompress.GzipParallel))
+}
+
+// readParallel collects elements of localName sent by reader.ReadParallel from r.
+func readParallel[T any](r io.ReadCloser, localName string, opts xmlparser.ParallelOptions) ([]*T, error) {
+	out := make(chan *T)
+	done := make(chan error, 1)
+	go func() {
+		defer close(out)
+		done <- reader.ReadParallel[T](context.Background(), r, localName, opts, nil, out)
+	}()
+	items := make([]*T, 0)
+	for v := range out {
+		items = append(items, v)
+	}
+	return items, <-done
+}
+
+func TestReleaseReadParallel(t *testing.T) {
+	r, err := os.Open("testdata/release.xml")
+	require.NoError(t, err)
+	sequential := make([]*XmlRelease, 0)
+	for item := range reader.NewReader[XmlRelease](context.Background(), r, "release").Observe() {
+		require.NoError(t, item.E)
+		sequential = append(sequential, item.V.(*XmlRelease))
+	}
+
+	r, err = os.Open("testdata/release.xml")
+	require.NoError(t, err)
+	parallel, err := readParallel[XmlRelease](r, "release", xmlparser.ParallelOptions{Workers: 3, Ordered: true})
+	require.NoError(t, err)
+	require.Len(t, sequential, 3)
+	require.Equal(t, sequential, parallel)
+}
+
+// BenchmarkReleaseRead decodes releases of testdata/release.xml, repeated to the size of a small dump,
+// sequentially and in parallel. Parallel decoding scales with GOMAXPROCS, as decoding is bound by CPU.
+func BenchmarkReleaseRead(b *testing.B) {
+	src, err := os.ReadFile("testdata/release.xml")
+	require.NoError(b, err)
+	body := bytes.TrimSuffix(bytes.TrimPrefix(bytes.TrimSpace(src), []byte("<releases>")), []byte("</releases>"))
+	dump := new(bytes.Buffer)
+	dump.WriteString("<releases>")
+	for i := 0; i < 2000; i++ {
+		dump.Write(body)
+	}
+	dump.WriteString("</releases>")
+
+	run := func(b *testing.B, read func(r io.ReadCloser) (int, error)) {
+		b.SetBytes(int64(dump.Len()))
+		for i := 0; i < b.N; i++ {
+			count, err := read(io.NopCloser(bytes.NewReader(dump.Bytes())))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if count != 6000 {
+				b.Fatalf("expected 6000 releases, got %d", count)
+			}
+		}
+	}
+	b.Run("sequential", func(b *testing.B) {
+		run(b, func(r io.ReadCloser) (int, error) {
+			count := 0
+			for item := range reader.NewReader[XmlRelease](context.Background(), r, "release").Observe() {
+				if item.E != nil {
+					return count, item.E
+				}
+				count++
+			}
+			return count, nil
+		})
+	})
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("parallel-%d", workers), func(b *testing.B) {
+			opts := xmlparser.ParallelOptions{Workers: workers, Ordered: true}
+			run(b, func(r io.ReadCloser) (int, error) {
+				items, err := readParallel[XmlRelease](r, "release", opts)
+				return len(items), err
+			})
+		})
+	}
+}
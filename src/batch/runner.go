@@ -2,90 +2,459 @@ package batch
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/knadh/koanf"
+	"github.com/sirupsen/logrus"
+	"github.com/state303/go-discogs/model"
+	"github.com/state303/go-discogs/src/cache"
 	"github.com/state303/go-discogs/src/data"
 	"github.com/state303/go-discogs/src/database"
+	"github.com/state303/go-discogs/src/deadletter"
+	"github.com/state303/go-discogs/src/history"
+	"github.com/state303/go-discogs/src/logging"
+	"github.com/state303/go-discogs/src/metrics"
+	"github.com/state303/go-discogs/src/tracing"
+	"gorm.io/gorm"
+	"path"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
-type Runner struct{}
+// lockPrefix names the database lock of each target schema, such as go-discogs/public.
+const lockPrefix = "go-discogs/"
 
-func (*Runner) Run(ctx context.Context, config *koanf.Koanf) error {
+// Runner loads dumps into the database, recording each run into batch_run table.
+type Runner struct {
+	// Version of the tool, recorded along with each run.
+	Version string
+}
+
+func (r *Runner) Run(ctx context.Context, config *koanf.Koanf) (err error) {
 	begin := time.Now()
-	if err := database.Connect(config.String("dsn")); err != nil {
+	stopMetrics, err := serveMetrics(config)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+	stopTracing, err := r.setupTracing(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+	if err = connect(config); err != nil {
+		return err
+	}
+	lock, err := acquireLock(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			logrus.WithField("lock", lock.Name()).WithError(err).Warn("failed to release lock")
+		}
+	}()
+
+	if err = history.Migrate(database.DB); err != nil {
+		return err
+	}
+	runRepo := history.NewRepository(database.DB)
+	run := &history.Run{
+		StartedAt: begin,
+		Version:   r.Version,
+		Types:     strings.Join(config.Strings("types"), ","),
+		Counts:    make(history.Counts),
+		Status:    history.StatusRunning,
+	}
+	if err = runRepo.Start(run); err != nil {
 		return err
 	}
+	defer func() { finishRun(runRepo, run, err) }()
+	ctx, span := tracing.Start(ctx, "batch", tracing.KeyType.StringSlice(config.Strings("types")))
+	defer func() { tracing.End(span, run.Updated, err) }()
 
+	fastLoad := config.Bool("fast-load")
 	if config.Bool("new") {
-		fmt.Println("execute DDL update...")
-		if err := RunDDL(database.DB); err != nil {
+		logrus.Info("execute DDL update...")
+		if err := RunDDLPhases(database.DB, getInitialPhases(fastLoad)...); err != nil {
 			return err
 		}
 	}
 
+	if err := data.Migrate(database.DB); err != nil {
+		return err
+	}
+
 	dataRepo := data.NewDataRepository(database.DB)
+	dataSrc, err := newSource(config)
+	if err != nil {
+		return err
+	}
 
 	if config.Bool("update") {
-		fmt.Println("begin update...")
-		if updated, err := data.UpdateData(ctx, dataRepo); err != nil {
+		logrus.Info("begin update...")
+		if updated, err := data.UpdateData(ctx, dataSrc, dataRepo); err != nil {
 			return err
 		} else {
-			fmt.Printf("update affected: %+v rows\n", updated)
+			logrus.WithField(logging.FieldCount, updated).Info("data dump records updated")
 		}
 	}
 
-	typeResourceMap, err := data.FetchFiles(config, dataRepo)
+	if err = resolveGeneration(config, dataRepo); err != nil {
+		return err
+	}
+	run.Generation = config.String("year") + "-" + config.String("month")
+	resumed := findResumed(runRepo, run, config.Bool("atomic"))
+
+	typeResourceMap, err := data.FetchFiles(ctx, config, dataSrc, dataRepo)
+	if err != nil {
+		return err
+	}
+
+	handler, sink, err := newErrorHandler(config)
 	if err != nil {
 		return err
 	}
+	defer func() { _ = sink.Close() }()
 
 	var (
 		b            = New()
-		totalUpdates = 0
+		totalUpdates int
 		chunk        = config.Int("chunk")
 		db           = database.DB
+		shadow       *Shadow
 		steps        = make([]Step, 0)
+		stepTypes    = make([]string, 0)
 	)
 
-	if hasArtist(config) {
-		order := NewOrder(ctx, chunk, typeResourceMap["artists"], db)
+	if config.Bool("atomic") {
+		if shadow, err = NewShadow(database.DB, config.String("dsn"), getInitialPhases(fastLoad)...); err != nil {
+			return err
+		}
+		logrus.WithField("schema", shadow.Name()).Info("loading into shadow schema...")
+		db = shadow.DB
+	}
+
+	newOrder := func(filepath string) Order {
+		return NewOrderWithErrorHandler(ctx, chunk, filepath, db, handler).
+			withConcurrency(config.Int("workers"), config.Int("writers")).
+			withGzip(config.String("gzip"))
+	}
+
+	if err = warmResumedCaches(db, resumed); err != nil {
+		return err
+	}
+
+	if hasArtist(config) && !resumed["artists"] {
+		order := newOrder(typeResourceMap["artists"])
 		steps = append(steps, b.UpdateArtist(order))
+		stepTypes = append(stepTypes, "artists")
 	}
 
-	if hasLabel(config) {
-		order := NewOrder(ctx, chunk, typeResourceMap["labels"], db)
+	if hasLabel(config) && !resumed["labels"] {
+		order := newOrder(typeResourceMap["labels"])
 		steps = append(steps, b.UpdateLabel(order))
+		stepTypes = append(stepTypes, "labels")
 	}
 
-	if hasMaster(config) {
-		order := NewOrder(ctx, chunk, typeResourceMap["masters"], db)
+	if hasMaster(config) && !resumed["masters"] {
+		order := newOrder(typeResourceMap["masters"])
 		steps = append(steps, b.UpdateMaster(order))
+		stepTypes = append(stepTypes, "masters")
 	}
 
-	if hasRelease(config) {
-		order := NewOrder(ctx, chunk, typeResourceMap["releases"], db)
+	if hasRelease(config) && !resumed["releases"] {
+		order := newOrder(typeResourceMap["releases"])
 		steps = append(steps, b.UpdateRelease(order))
+		stepTypes = append(stepTypes, "releases")
 	}
 
-	for i := range steps {
-		r := steps[i]()
-		totalUpdates += r.Count()
-		if r.IsErr() {
-			err = r.Err()
-			break
+	run.ETags = strings.Join(getETags(config, dataRepo, stepTypes), ",")
+	loaded := make([]string, 0)
+	stepBegin := time.Now()
+	totalUpdates, err = runSteps(ctx, steps, func(i, count int, err error) {
+		run.Counts[stepTypes[i]] = count
+		log := logrus.WithFields(logrus.Fields{
+			logging.FieldStep:    i + 1,
+			logging.FieldType:    stepTypes[i],
+			logging.FieldCount:   count,
+			logging.FieldElapsed: logging.Elapsed(stepBegin),
+		})
+		if err == nil {
+			loaded = append(loaded, stepTypes[i])
+			log.Info("step completed")
+		} else {
+			log.WithError(err).Error("step failed")
+		}
+		stepBegin = time.Now()
+	})
+	run.Updated = totalUpdates
+
+	if err == nil && fastLoad {
+		logrus.Info("building deferred indexes and constraints...")
+		err = RunDeferredDDL(db)
+	}
+
+	if shadow != nil {
+		if err == nil {
+			logrus.WithField("schema", shadow.Name()).Info("swapping shadow schema into place...")
+			err = shadow.Swap()
+		} else if dropErr := shadow.Drop(); dropErr != nil {
+			logrus.WithField("schema", shadow.Name()).WithError(dropErr).Warn("failed to drop shadow schema")
+		}
+		if err != nil {
+			loaded = loaded[:0] // nothing is visible unless swapped
+		}
+	}
+	run.Completed = strings.Join(append(keys(resumed), loaded...), ",")
+
+	for _, typ := range loaded {
+		markLoaded(config, dataRepo, typ)
+		if config.Bool("purge") {
+			purgeFile(config.String("data"), typeResourceMap[typ])
 		}
 	}
 
 	printResult(begin, totalUpdates, err)
+	printSkipped(config, handler)
+	if errors.Is(err, context.Canceled) {
+		logrus.WithField(logging.FieldType, stepTypes[len(loaded):]).Warn("interrupted: not loaded. run again with the same flags to resume")
+	}
+	if err == nil && config.Int("keep") > 0 {
+		if dataDir := config.String("data"); isSourceDir(config.String("source"), dataDir) {
+			logrus.WithField("dir", dataDir).Info("skipped retention: data dir is the dump source")
+		} else {
+			err = logRetention(dataDir, config.Int("keep"))
+		}
+	}
 	return err
 }
 
+// serveMetrics serves prometheus metrics at configured metrics-addr until the returned func is called.
+// Nothing is served if metrics-addr is empty.
+func serveMetrics(config *koanf.Koanf) (func(), error) {
+	addr := config.String("metrics-addr")
+	if len(addr) == 0 {
+		return func() {}, nil
+	}
+	srv, err := metrics.Serve(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serve metrics: %w", err)
+	}
+	logrus.WithField("addr", addr).Info("serving metrics at /metrics")
+	return func() { _ = srv.Close() }, nil
+}
+
+// setupTracing exports spans to configured trace-exporter until the returned func is called, which flushes pending spans.
+func (r *Runner) setupTracing(ctx context.Context, config *koanf.Koanf) (func(), error) {
+	shutdown, err := tracing.Setup(ctx, tracing.Options{
+		Exporter: config.String("trace-exporter"),
+		Endpoint: config.String("trace-endpoint"),
+		File:     config.String("trace-file"),
+		Version:  r.Version,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup tracing: %w", err)
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			logrus.WithError(err).Warn("failed to export spans")
+		}
+	}, nil
+}
+
+// connect connects to configured dsn, placing tables into configured schema with configured table prefix.
+func connect(config *koanf.Koanf) error {
+	database.Names = database.Naming{Schema: config.String("schema"), TablePrefix: config.String("table-prefix")}
+	return database.Connect(config.String("dsn"))
+}
+
+// acquireLock takes the database lock of the target schema, so that runs against one database never overlap.
+// It fails fast, or waits up to configured lock-timeout for the other run to finish.
+func acquireLock(ctx context.Context, config *koanf.Koanf) (*database.Lock, error) {
+	var schema string
+	if err := database.DB.Raw("SELECT current_schema()").Scan(&schema).Error; err != nil {
+		return nil, err
+	}
+	name := lockPrefix + schema
+	if prefix := database.Names.TablePrefix; len(prefix) > 0 {
+		name += "/" + prefix
+	}
+	timeout := config.Duration("lock-timeout")
+	if timeout > 0 {
+		logrus.WithFields(logrus.Fields{"lock": name, "timeout": timeout.String()}).Info("acquiring lock...")
+	}
+	lock, err := database.AcquireLock(ctx, database.DB, name, timeout)
+	if errors.Is(err, database.ErrLocked) {
+		return nil, fmt.Errorf("another run is in progress on %+v: %w", schema, err)
+	}
+	return lock, err
+}
+
+// getInitialPhases returns DDL phases to run before loading. Fast load creates tables only,
+// leaving indexes and constraints to RunDeferredDDL after every step.
+func getInitialPhases(fastLoad bool) []DDLPhase {
+	if fastLoad {
+		return []DDLPhase{PhaseTables}
+	}
+	return []DDLPhase{PhaseTables, PhaseIndexes, PhaseConstraints}
+}
+
+// newErrorHandler returns ErrorHandler of configured on-error policy, with dead-letter sink of configured destination.
+// Dead-letters are written into data dir by default.
+func newErrorHandler(config *koanf.Koanf) (ErrorHandler, deadletter.Sink, error) {
+	policy, err := ParseErrorPolicy(config.String("on-error"))
+	if err != nil {
+		return nil, nil, err
+	}
+	sink, err := deadletter.NewSink(getDeadLetterDest(config), database.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewErrorHandler(policy, sink), sink, nil
+}
+
+func getDeadLetterDest(config *koanf.Koanf) string {
+	if dest := config.String("dead-letter"); len(dest) > 0 {
+		return dest
+	}
+	return path.Join(config.String("data"), "deadletter.jsonl")
+}
+
+// printSkipped reports number of elements and rows skipped by the error policy.
+func printSkipped(config *koanf.Koanf, handler ErrorHandler) {
+	n := handler.Count()
+	if n == 0 {
+		return
+	}
+	if handler.Policy() == PolicyDeadLetter {
+		logrus.WithFields(logrus.Fields{logging.FieldCount: n, "dest": getDeadLetterDest(config)}).Warn("dead-lettered records")
+	} else {
+		logrus.WithField(logging.FieldCount, n).Warn("skipped records")
+	}
+}
+
+// resolveGeneration sets year and month of config to the generation chosen by latest and fallback, then prints it.
+func resolveGeneration(config *koanf.Koanf, repo data.Repository) error {
+	latest := config.Bool("latest") || config.String("year") == "latest"
+	gen, err := data.ResolveGeneration(repo, config.Strings("types"), config.String("year"), config.String("month"), latest, config.Bool("fallback"))
+	if err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{"generation": gen.Format("2006-01"), logging.FieldType: config.Strings("types")}).Info("using generation")
+	if err = config.Set("year", gen.Format("2006")); err != nil {
+		return err
+	}
+	return config.Set("month", gen.Format("01"))
+}
+
+// getETags returns etags of dump data of given types in configured generation. Types missing from repository are omitted.
+func getETags(config *koanf.Koanf, repo data.Repository, types []string) []string {
+	etags := make([]string, 0, len(types))
+	for _, typ := range types {
+		if d, err := repo.FindByYearMonthType(config.String("year"), config.String("month"), typ); err == nil {
+			etags = append(etags, d.ETag)
+		}
+	}
+	return etags
+}
+
+// finishRun records outcome of the run. Failure is only reported, as it must not fail the load itself.
+func finishRun(repo history.Repository, run *history.Run, err error) {
+	now := time.Now()
+	run.EndedAt = &now
+	run.Status = history.GetStatus(err)
+	if err != nil {
+		run.Error = err.Error()
+	}
+	if err := repo.Finish(run); err != nil {
+		logrus.WithField("run", run.ID).WithError(err).Warn("failed to record batch run")
+	}
+}
+
+// findResumed returns types completed by the last run if this run resumes it, as history.Resumed tells. Loads into a
+// shadow schema are never resumed, as types loaded by the former run are not in the new shadow schema.
+func findResumed(repo history.Repository, run *history.Run, atomic bool) map[string]bool {
+	resumed := make(map[string]bool)
+	if atomic {
+		return resumed
+	}
+	runs, err := repo.FindLast(2)
+	if err != nil {
+		logrus.WithError(err).Warn("failed to find the last run to resume")
+		return resumed
+	}
+	for _, prev := range runs {
+		if prev.ID == run.ID {
+			continue
+		}
+		for _, typ := range history.Resumed(prev, run) {
+			resumed[typ] = true
+		}
+		if len(resumed) > 0 {
+			logrus.WithFields(logrus.Fields{"run": prev.ID, logging.FieldType: keys(resumed)}).
+				Info("resuming interrupted run: skipped types already loaded")
+		}
+		break
+	}
+	return resumed
+}
+
+// warmResumedCaches caches ids of artists, labels and masters loaded by the resumed run, so that steps following
+// refer to them as if their steps ran.
+func warmResumedCaches(db *gorm.DB, resumed map[string]bool) error {
+	caches := []struct {
+		typ   string
+		model interface{}
+		cache *sync.Map
+	}{
+		{"artists", &model.Artist{}, cache.ArtistIDCache},
+		{"labels", &model.Label{}, cache.LabelIDCache},
+		{"masters", &model.Master{}, cache.MasterIDCache},
+	}
+	for _, c := range caches {
+		if !resumed[c.typ] {
+			continue
+		}
+		count, err := warmIDCache(db, c.model, c.cache)
+		if err != nil {
+			return fmt.Errorf("failed to cache ids of %+v: %w", c.typ, err)
+		}
+		logrus.WithFields(logrus.Fields{logging.FieldType: c.typ, logging.FieldCount: count}).Info("cached ids of resumed type")
+	}
+	return nil
+}
+
+// keys returns keys of m in sorted order.
+func keys(m map[string]bool) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// markLoaded records load time of the data of given types. Failure is only reported, as the load itself succeeded.
+func markLoaded(config *koanf.Koanf, repo data.Repository, typ string) {
+	d, err := repo.FindByYearMonthType(config.String("year"), config.String("month"), typ)
+	if err == nil {
+		err = repo.MarkLoaded(d.ETag, time.Now())
+	}
+	if err != nil {
+		logrus.WithField(logging.FieldType, typ).WithError(err).Warn("failed to mark as loaded")
+	}
+}
+
 func printResult(begin time.Time, total int, err error) {
-	took := time.Since(begin).Truncate(time.Second).String()
-	s := fmt.Sprintf("updated %+v records in %+v.", total, took)
+	log := logrus.WithFields(logrus.Fields{logging.FieldCount: total, logging.FieldElapsed: logging.Elapsed(begin)})
 	if err != nil {
-		s += fmt.Sprintf(" [error: %+v]", err)
+		log.WithError(err).Error("batch failed")
+		return
 	}
-	fmt.Println(s)
+	log.Info("batch completed")
 }
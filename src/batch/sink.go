@@ -0,0 +1,59 @@
+package batch
+
+import (
+	"context"
+	"github.com/state303/go-discogs/model"
+	"github.com/state303/go-discogs/src/result"
+	"github.com/state303/go-discogs/src/tracing"
+)
+
+// Sink receives rows produced by steps, such as a database, files or a stream. Steps write through Sink only,
+// hence any Sink can be targeted with no change of steps. Rows are given as slices of pointers of model types.
+// Sink is shared among chunks written in parallel, hence must be safe for concurrent use.
+type Sink interface {
+	// Upsert writes entities, such as artists and releases, updating those already written.
+	Upsert(ctx context.Context, entities ...interface{}) result.Result
+	// Link writes rows of link tables, such as release_artist, leaving those already written as is.
+	Link(ctx context.Context, links ...interface{}) result.Result
+	// UpdateLabelParents sets parent of each label, which are written already.
+	UpdateLabelParents(ctx context.Context, labels []*model.Label) result.Result
+	// ResolveGenres writes genres of names missing, then returns ids of every genre of names by name.
+	ResolveGenres(ctx context.Context, names []string) (map[string]int32, error)
+	// ResolveStyles writes styles of names missing, then returns ids of every style of names by name.
+	ResolveStyles(ctx context.Context, names []string) (map[string]int32, error)
+	// Chunk calls write with Sink of which writes are kept together, or none of them if write fails.
+	Chunk(ctx context.Context, write func(s Sink) result.Result) result.Result
+}
+
+// chunkWriter writes rows of a chunk through Sink.
+type chunkWriter func(ctx context.Context, s Sink) result.Result
+
+// upsertThenLink returns chunkWriter writing entities then their links, stopping at the first error.
+func upsertThenLink(entities interface{}, links ...interface{}) chunkWriter {
+	return func(ctx context.Context, s Sink) result.Result {
+		r := s.Upsert(ctx, entities)
+		if r.IsErr() {
+			return r
+		}
+		return r.Sum(s.Link(ctx, links...))
+	}
+}
+
+// link returns chunkWriter writing links only.
+func link(links ...interface{}) chunkWriter {
+	return func(ctx context.Context, s Sink) result.Result {
+		return s.Link(ctx, links...)
+	}
+}
+
+// writeChunk returns func writing rows of a chunk in a single Sink chunk, so that a failure leaves none of them.
+// The chunk is traced as a child span of the step, though it is never cancelled along with the step.
+func writeChunk(order Order) func(write chunkWriter) result.Result {
+	return func(write chunkWriter) result.Result {
+		ctx, span := tracing.Start(order.getContext(), "chunk")
+		ctx = tracing.Detach(ctx)
+		res := order.getSink().Chunk(ctx, func(s Sink) result.Result { return write(ctx, s) })
+		tracing.End(span, res.Count(), res.Err())
+		return res
+	}
+}
@@ -0,0 +1,109 @@
+package batch
+
+import (
+	"context"
+	"github.com/state303/go-discogs/model"
+	"github.com/state303/go-discogs/src/cache"
+	"github.com/state303/go-discogs/src/result"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// memorySink records rows by type name, resolving genres and styles to ids of their order.
+type memorySink struct {
+	mu      sync.Mutex
+	rows    map[string]int
+	parents map[int32]int32
+	lookups map[string]int32
+}
+
+func newMemorySink() *memorySink {
+	return &memorySink{rows: make(map[string]int), parents: make(map[int32]int32), lookups: make(map[string]int32)}
+}
+
+func (m *memorySink) record(slices ...interface{}) result.Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := 0
+	for _, slice := range slices {
+		v := reflect.ValueOf(slice)
+		if v.Kind() != reflect.Slice || v.Len() == 0 {
+			continue
+		}
+		m.rows[getTypeName(v.Index(0).Interface())] += v.Len()
+		count += v.Len()
+	}
+	return result.NewResult(count, nil)
+}
+
+func (m *memorySink) Upsert(_ context.Context, entities ...interface{}) result.Result {
+	return m.record(entities...)
+}
+
+func (m *memorySink) Link(_ context.Context, links ...interface{}) result.Result {
+	return m.record(links...)
+}
+
+func (m *memorySink) UpdateLabelParents(_ context.Context, labels []*model.Label) result.Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, l := range labels {
+		m.parents[l.ID] = *l.ParentID
+	}
+	return result.NewResult(len(labels), nil)
+}
+
+func (m *memorySink) resolve(kind string, names []string) (map[string]int32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make(map[string]int32)
+	for _, name := range names {
+		id, ok := m.lookups[kind+name]
+		if !ok {
+			id = int32(len(m.lookups) + 1)
+			m.lookups[kind+name] = id
+		}
+		ids[name] = id
+	}
+	return ids, nil
+}
+
+func (m *memorySink) ResolveGenres(_ context.Context, names []string) (map[string]int32, error) {
+	return m.resolve("genre/", names)
+}
+
+func (m *memorySink) ResolveStyles(_ context.Context, names []string) (map[string]int32, error) {
+	return m.resolve("style/", names)
+}
+
+func (m *memorySink) Chunk(_ context.Context, write func(s Sink) result.Result) result.Result {
+	return write(m)
+}
+
+func TestStepsWriteIntoSink(t *testing.T) {
+	cache.GenreCache, cache.StyleCache = &sync.Map{}, &sync.Map{} // resolved by the sink only
+	sink := newMemorySink()
+	handler := NewErrorHandler(PolicyFail, nil)
+	run := func(step func(Order) Step, filepath string) result.Result {
+		return step(NewOrderWithSink(context.Background(), 2, filepath, sink, handler))()
+	}
+
+	require.NoError(t, run(GetArtistStep, "testdata/artist.xml.gz").Err())
+	require.NoError(t, run(GetLabelStep, "testdata/label.xml.gz").Err())
+	require.NoError(t, run(GetMasterStep, "testdata/master.xml.gz").Err())
+	res := run(GetReleaseStep, "testdata/release.xml.gz")
+	require.NoError(t, res.Err())
+	require.NotZero(t, res.Count())
+
+	assert.Equal(t, 3, sink.rows["Artist"])
+	assert.Equal(t, 5, sink.rows["Label"])
+	assert.Equal(t, 3, sink.rows["Master"])
+	assert.Equal(t, 3, sink.rows["Release"])
+	assert.NotZero(t, sink.rows["ReleaseTrack"])
+	assert.NotZero(t, sink.rows["MasterGenre"])
+	assert.NotZero(t, sink.rows["ReleaseStyle"])
+	assert.NotEmpty(t, sink.lookups)
+}
@@ -0,0 +1,105 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/knadh/koanf"
+	"github.com/state303/go-discogs/src/data"
+	"github.com/state303/go-discogs/src/database"
+	"github.com/state303/go-discogs/src/history"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Freshness is the generation a type currently reflects, which is the dump loaded last.
+type Freshness struct {
+	Type       string    `json:"type"`
+	Generation string    `json:"generation"`
+	LoadedAt   time.Time `json:"loaded_at"`
+}
+
+// Status is freshness of each type along with the last runs.
+type Status struct {
+	Freshness []*Freshness   `json:"freshness"`
+	Runs      []*history.Run `json:"runs"`
+}
+
+// StatusRunner prints freshness of each type and the last batch runs.
+type StatusRunner struct{}
+
+func (*StatusRunner) Run(config *koanf.Koanf, w io.Writer) error {
+	if err := connect(config); err != nil {
+		return err
+	}
+	if err := data.Migrate(database.DB); err != nil {
+		return err
+	}
+	if err := history.Migrate(database.DB); err != nil {
+		return err
+	}
+	return PrintStatus(config, data.NewDataRepository(database.DB), history.NewRepository(database.DB), w)
+}
+
+// PrintStatus writes freshness of configured types and the last runs, either as tables or json.
+func PrintStatus(config *koanf.Koanf, dataRepo data.Repository, runRepo history.Repository, w io.Writer) error {
+	items, err := dataRepo.FindAll(data.Filter{Types: config.Strings("types")})
+	if err != nil {
+		return err
+	}
+	runs, err := runRepo.FindLast(config.Int("last"))
+	if err != nil {
+		return err
+	}
+	status := &Status{Freshness: getFreshness(items), Runs: runs}
+
+	if config.String("output") == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TYPE\tGENERATION\tLOADED")
+	for _, f := range status.Freshness {
+		_, _ = fmt.Fprintf(tw, "%+v\t%+v\t%+v\n", f.Type, f.Generation, f.LoadedAt.Format(time.RFC3339))
+	}
+	_, _ = fmt.Fprintln(tw)
+	_, _ = fmt.Fprintln(tw, "ID\tSTARTED\tTOOK\tSTATUS\tGENERATION\tTYPES\tUPDATED\tVERSION\tERROR")
+	for _, r := range status.Runs {
+		took := "-"
+		if r.EndedAt != nil {
+			took = r.EndedAt.Sub(r.StartedAt).Truncate(time.Second).String()
+		}
+		_, _ = fmt.Fprintf(tw, "%+v\t%+v\t%+v\t%+v\t%+v\t%+v\t%+v\t%+v\t%+v\n",
+			r.ID, r.StartedAt.Format(time.RFC3339), took, r.Status, orDash(r.Generation), r.Types, r.Updated, orDash(r.Version), orDash(r.Error))
+	}
+	return tw.Flush()
+}
+
+// getFreshness returns the dump loaded last of each type, ordered by type.
+func getFreshness(items []*data.Data) []*Freshness {
+	latest := make(map[string]*data.Data)
+	for _, item := range items {
+		if item.LoadedAt == nil {
+			continue
+		}
+		if l, ok := latest[item.TargetType]; !ok || item.LoadedAt.After(*l.LoadedAt) {
+			latest[item.TargetType] = item
+		}
+	}
+	res := make([]*Freshness, 0, len(latest))
+	for typ, item := range latest {
+		res = append(res, &Freshness{Type: typ, Generation: item.GeneratedAt.Format("2006-01"), LoadedAt: *item.LoadedAt})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Type < res[j].Type })
+	return res
+}
+
+func orDash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,84 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/state303/go-discogs/src/data"
+	"github.com/state303/go-discogs/src/history"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeRunRepository struct {
+	runs []*history.Run
+}
+
+func (f *fakeRunRepository) Start(run *history.Run) error {
+	run.ID = int64(len(f.runs) + 1)
+	f.runs = append([]*history.Run{run}, f.runs...)
+	return nil
+}
+
+func (f *fakeRunRepository) Finish(*history.Run) error {
+	return nil
+}
+
+func (f *fakeRunRepository) FindLast(n int) ([]*history.Run, error) {
+	if n < len(f.runs) {
+		return f.runs[:n], nil
+	}
+	return f.runs, nil
+}
+
+func TestPrintStatus(t *testing.T) {
+	dataRepo := data.NewMemoryRepository()
+	_, _ = dataRepo.BatchInsert([]*data.Data{
+		{ETag: "1", GeneratedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), TargetType: "artists"},
+		{ETag: "2", GeneratedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), TargetType: "artists"},
+		{ETag: "3", GeneratedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), TargetType: "labels"},
+	})
+	require.NoError(t, dataRepo.MarkLoaded("2", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)))
+	require.NoError(t, dataRepo.MarkLoaded("1", time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)))
+
+	runRepo := new(fakeRunRepository)
+	ended := time.Date(2024, 3, 6, 0, 10, 0, 0, time.UTC)
+	_ = runRepo.Start(&history.Run{StartedAt: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), Status: history.StatusFailed, Error: "boom"})
+	_ = runRepo.Start(&history.Run{StartedAt: time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), EndedAt: &ended, Status: history.StatusSucceeded,
+		Generation: "2024-02", Types: "artists", Counts: history.Counts{"artists": 42}, Updated: 42, Version: "v1.0.0"})
+
+	getConfig := func(t *testing.T, yml string) *koanf.Koanf {
+		k := koanf.New(".")
+		require.NoError(t, k.Load(rawbytes.Provider([]byte(yml)), yaml.Parser()))
+		return k
+	}
+
+	t.Run("prints json", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, PrintStatus(getConfig(t, "last: 1\noutput: json"), dataRepo, runRepo, buf))
+		var status Status
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &status))
+		require.Len(t, status.Freshness, 1)
+		require.Equal(t, "artists", status.Freshness[0].Type)
+		require.Equal(t, "2024-02", status.Freshness[0].Generation) // loaded last, even though older
+		require.Len(t, status.Runs, 1)
+		require.Equal(t, history.Counts{"artists": 42}, status.Runs[0].Counts)
+	})
+
+	t.Run("prints tables", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, PrintStatus(getConfig(t, "last: 10"), dataRepo, runRepo, buf))
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 6)
+		require.Contains(t, lines[0], "GENERATION")
+		require.Contains(t, lines[1], "2024-02")
+		require.Contains(t, lines[3], "STATUS")
+		require.Contains(t, lines[4], "10m0s")
+		require.Contains(t, lines[4], "v1.0.0")
+		require.Contains(t, lines[5], "boom")
+	})
+}
@@ -1,16 +1,14 @@
 package batch
 
-import (
-	"github.com/reactivex/rxgo/v2"
-)
-
+// Transformable is an element transformed into a model before written.
 type Transformable interface {
-	Transform() rxgo.Observable
+	Transform() interface{}
 }
 
-func Transform(item rxgo.Item) rxgo.Observable {
-	if v, ok := item.V.(Transformable); ok {
-		return v.Transform()
+// Transform returns model of v if Transformable, otherwise v as is.
+func Transform(v interface{}) interface{} {
+	if t, ok := v.(Transformable); ok {
+		return t.Transform()
 	}
-	return rxgo.Just(item.V)()
+	return v
 }
@@ -1,7 +1,6 @@
 package batch
 
 import (
-	"github.com/reactivex/rxgo/v2"
 	"github.com/state303/go-discogs/model"
 	"github.com/state303/go-discogs/src/cache"
 	"github.com/state303/go-discogs/src/dateparser"
@@ -24,14 +23,14 @@ type XmlArtist struct {
 	RealName    *string `xml:"realname"`
 }
 
-func (a *XmlArtist) Transform() rxgo.Observable {
-	return rxgo.Just(&model.Artist{
+func (a *XmlArtist) Transform() interface{} {
+	return &model.Artist{
 		ID:          a.ID,
 		DataQuality: helper.FilterStr(a.DataQuality),
 		Name:        helper.FilterStr(a.Name),
 		Profile:     helper.FilterStr(a.Profile),
 		RealName:    helper.FilterStr(a.RealName),
-	})()
+	}
 }
 
 type XmlArtistRelation struct {
@@ -90,14 +89,14 @@ type XmlLabel struct {
 	DataQuality *string `xml:"data_quality"`
 }
 
-func (l *XmlLabel) Transform() rxgo.Observable {
-	return rxgo.Just(&model.Label{
+func (l *XmlLabel) Transform() interface{} {
+	return &model.Label{
 		ID:          l.ID,
 		Name:        l.Name,
 		ContactInfo: l.ContactInfo,
 		Profile:     l.Profile,
 		DataQuality: l.DataQuality,
-	})()
+	}
 }
 
 type XmlLabelRelation struct {
@@ -137,13 +136,13 @@ type XmlGenreStyle struct {
 	Genres []string `xml:"genres>genre"`
 }
 
-func (m *XmlMaster) Transform() rxgo.Observable {
-	return rxgo.Just(&model.Master{
+func (m *XmlMaster) Transform() interface{} {
+	return &model.Master{
 		ID:           m.ID,
 		Title:        m.Title,
 		DataQuality:  m.DataQuality,
 		ReleasedYear: m.Year,
-	})()
+	}
 }
 
 type XmlMasterRelation struct {
@@ -165,7 +164,7 @@ type XmlVideo struct {
 
 func (m *XmlMasterRelation) GetStyles() []*model.Style {
 	s := make([]*model.Style, 0)
-	for _, style := range unique.Slice(m.Styles) {
+	for _, style := range unique.Values(m.Styles) {
 		if style = strings.TrimSpace(style); len(style) > 0 {
 			s = append(s, &model.Style{Name: style})
 		}
@@ -175,7 +174,7 @@ func (m *XmlMasterRelation) GetStyles() []*model.Style {
 
 func (m *XmlMasterRelation) GetGenres() []*model.Genre {
 	g := make([]*model.Genre, 0)
-	for _, genre := range unique.Slice(m.Genres) {
+	for _, genre := range unique.Values(m.Genres) {
 		if genre = strings.TrimSpace(genre); len(genre) > 0 {
 			g = append(g, &model.Genre{Name: genre})
 		}
@@ -257,7 +256,7 @@ type XmlReleaseMasterInfo struct {
 	IsMaster bool   `xml:"is_main_release,attr"`
 }
 
-func (m *XmlRelease) Transform() rxgo.Observable {
+func (m *XmlRelease) Transform() interface{} {
 	var year, month, day *int16
 	if ymd := m.ListedReleaseDate; ymd != nil {
 		year, month, day = dateparser.ParseYMD(*ymd)
@@ -271,7 +270,7 @@ func (m *XmlRelease) Transform() rxgo.Observable {
 		}
 	}
 
-	return rxgo.Just(&model.Release{
+	return &model.Release{
 		ID:                m.ID,
 		Title:             helper.FilterStr(m.Title),
 		Country:           helper.FilterStr(m.Country),
@@ -284,7 +283,7 @@ func (m *XmlRelease) Transform() rxgo.Observable {
 		MasterID:          masterID,
 		Notes:             helper.FilterStr(m.Notes),
 		Status:            helper.FilterStr(m.Status),
-	})()
+	}
 }
 
 type XmlLabelRelease struct {
@@ -344,7 +343,7 @@ type XmlReleaseRelation struct {
 
 func (r *XmlReleaseRelation) GetGenres() []*model.Genre {
 	genres := make([]*model.Genre, 0)
-	for _, v := range unique.Slice(r.Genres) {
+	for _, v := range unique.Values(r.Genres) {
 		if v = strings.TrimSpace(v); len(v) > 0 {
 			genres = append(genres, &model.Genre{Name: v})
 		}
@@ -354,7 +353,7 @@ func (r *XmlReleaseRelation) GetGenres() []*model.Genre {
 
 func (r *XmlReleaseRelation) GetStyles() []*model.Style {
 	styles := make([]*model.Style, 0)
-	for _, v := range unique.Slice(r.Styles) {
+	for _, v := range unique.Values(r.Styles) {
 		if v = strings.TrimSpace(v); len(v) > 0 {
 			styles = append(styles, &model.Style{Name: v})
 		}
@@ -6,11 +6,9 @@ import (
 	"github.com/knadh/koanf"
 	"github.com/reactivex/rxgo/v2"
 	"github.com/state303/go-discogs/src/client"
-	"github.com/state303/go-discogs/src/file"
 	"github.com/state303/go-discogs/src/helper"
 	"github.com/state303/go-discogs/src/xmlparser"
 	"io"
-	"path"
 	"regexp"
 	"strings"
 	"sync"
@@ -110,14 +108,14 @@ func isKnownType(typeStr string) bool {
 	return dataTypesRegexp.MatchString(typeStr)
 }
 
-func DispatchChecksumFetch() func(context.Context, interface{}) (interface{}, error) {
+func DispatchChecksumFetch(baseUrl string) func(context.Context, interface{}) (interface{}, error) {
 	return func(ctx context.Context, i interface{}) (interface{}, error) {
 		if dump := i.(*Data); dump.TargetType == "checksum" {
 			checksumFetchWg.Add(1)
 			go func() {
 				defer checksumFetchWg.Done()
 				select {
-				case v := <-getClient().Get(ctx, DiscogsS3BaseUrl+dump.Uri).Observe():
+				case v := <-getClient().Get(ctx, baseUrl+dump.Uri).Observe():
 					if !v.Error() {
 						storeChecksum(string(v.V.([]byte)))
 					}
@@ -156,43 +154,22 @@ func ParseDumpModel(ctx context.Context) func(item rxgo.Item) rxgo.Observable {
 	}
 }
 
-func UpdateData(ctx context.Context, repo Repository) (int, error) {
-	c := client.NewClient()
-
-	items, err := c.Get(ctx, DiscogsS3BaseUrl).
-		FlatMap(ParseDumpModel(ctx)).
-		Filter(NotNilFilter()).
-		Filter(ValidUriFilter()).
-		Map(PopulateFromUri()).
-		Map(DispatchChecksumFetch(), rxgo.WithCPUPool()). // NOT ordered
-		ToSlice(400, rxgo.WithContext(ctx))               // known size: 777 and beyond
-
+// UpdateData lists every dump data from given Source then inserts them into the repository.
+func UpdateData(ctx context.Context, src Source, repo Repository) (int, error) {
+	items, err := src.List(ctx)
 	if err != nil {
 		return -1, err
 	}
 
-	// wait until checksum fetch is complete
-	wgSig := make(chan struct{}, 1)
-	go func() {
-		defer close(wgSig)
-		checksumFetchWg.Wait()
-		wgSig <- struct{}{}
-	}()
-
-	select {
-	case <-ctx.Done():
-		return 0, nil
-	case <-wgSig:
-		break
-	}
-
 	res := <-rxgo.Just(items)().
-		Map(SetChecksumValues(checksumMap)).
 		Map(helper.SliceMapper[*Data]()).
 		Filter(NotNilFilter()).
 		Reduce(helper.SliceReducer[*Data]()).
 		Map(BatchInsertItems(repo)).
 		Observe()
+	if res.V == nil {
+		return 0, res.E
+	}
 	return res.V.(int), res.E
 }
 
@@ -203,25 +180,46 @@ func BatchInsertItems(repo Repository) func(ctx context.Context, i interface{})
 	}
 }
 
-func FetchFiles(k *koanf.Koanf, dataRepo Repository) (map[string]string, error) {
-	typeResourceMap := make(map[string]string)
+// FetchFiles places dump files of configured year, month and types from given Source into the data directory.
+// Files of each types are fetched concurrently until ctx is done. Returns local file path of each types.
+func FetchFiles(ctx context.Context, k *koanf.Koanf, src Source, dataRepo Repository) (map[string]string, error) {
 	year, month := k.String("year"), k.String("month")
 	dataRootDir := k.String("data")
-	handler := file.NewHandler()
+	targets := make(map[string]*Data)
 	for _, typ := range k.Strings("types") {
 		d, err := dataRepo.FindByYearMonthType(year, month, typ)
 		if err != nil {
 			return nil, err
 		}
-		var (
-			resourceURI = DiscogsS3BaseUrl + d.Uri
-			targetPath  = path.Join(dataRootDir, helper.GetLastUriSegment(d.Uri))
-		)
-		err = handler.FetchAndCheck(resourceURI, targetPath, d.Checksum)
-		if err != nil {
-			return nil, err
-		}
-		typeResourceMap[typ] = targetPath
+		targets[typ] = d
+	}
+
+	var (
+		typeResourceMap = make(map[string]string)
+		wg              = new(sync.WaitGroup)
+		resMu           = new(sync.Mutex)
+		err             error
+	)
+	for typ, d := range targets {
+		wg.Add(1)
+		go func(typ string, d *Data) {
+			defer wg.Done()
+			targetPath, fetchErr := src.Fetch(ctx, d, dataRootDir)
+			resMu.Lock()
+			defer resMu.Unlock()
+			if fetchErr != nil {
+				if err == nil {
+					err = fetchErr
+				}
+				return
+			}
+			typeResourceMap[typ] = targetPath
+		}(typ, d)
+	}
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
 	}
 	return typeResourceMap, nil
 }
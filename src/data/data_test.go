@@ -163,7 +163,7 @@ func TestDispatchChecksumFetch(t *testing.T) {
 e0e22f8501c2013eda69071a16e35ff785c0a135dee009fe2b67349f907709eb *discogs_20080309_releases.xml.gz`
 		getClient = getClientStub([]byte(data), nil)
 		dump := &Data{TargetType: "checksum", Uri: ""}
-		v, err := DispatchChecksumFetch()(context.Background(), dump)
+		v, err := DispatchChecksumFetch(DiscogsS3BaseUrl)(context.Background(), dump)
 		assert.NoError(t, err)
 		assert.Equal(t, dump, v.(*Data))
 	})
@@ -237,6 +237,19 @@ func (r *RepositoryStub) FindByYearMonthType(year, month, typ string) (*Data, er
 	return nil, errors.New("record not found")
 }
 
+func (r *RepositoryStub) FindAll(filter Filter) ([]*Data, error) {
+	return filter.keepLatest(r.items), nil
+}
+
+func (r *RepositoryStub) MarkLoaded(etag string, at time.Time) error {
+	for _, v := range r.items {
+		if v.ETag == etag {
+			v.LoadedAt = &at
+		}
+	}
+	return nil
+}
+
 func TestUpdateData(t *testing.T) {
 	data := resource.Read("testdata/update-data-test.xml")
 	server := testserver.NewServer(func(requests []*testserver.HttpRequest, w http.ResponseWriter, r *http.Request) {
@@ -256,7 +269,7 @@ e0e22f8501c2013eda69071a16e35ff785c0a135dee009fe2b67349f907709eb *discogs_200803
 		defer func() { DiscogsS3BaseUrl = origin }()
 		DiscogsS3BaseUrl = server.URL + "/"
 		repo := &RepositoryStub{items: make([]*Data, 0)}
-		updateCount, err := UpdateData(context.Background(), repo)
+		updateCount, err := UpdateData(context.Background(), NewSource("s3", file.NewHandler()), repo)
 		require.NoError(t, err)
 		require.Equal(t, 4, updateCount)
 		require.Len(t, repo.items, 4)
@@ -302,7 +315,7 @@ month: 10
 `)), yaml.Parser())
 		require.NoError(t, err)
 		repo := &RepositoryStub{}
-		result, err := FetchFiles(k, repo)
+		result, err := FetchFiles(context.Background(), k, NewSource("s3", file.NewHandler()), repo)
 		require.ErrorContains(t, err, "not found")
 		require.Nil(t, result)
 		fmt.Println(err.Error())
@@ -335,7 +348,7 @@ data: testdata
 		require.NoError(t, err)
 		require.Equal(t, 1, insert)
 
-		result, err := FetchFiles(k, repo)
+		result, err := FetchFiles(context.Background(), k, NewSource("s3", file.NewHandler()), repo)
 		require.NoError(t, err)
 		require.NotNil(t, result)
 
@@ -380,7 +393,7 @@ data: testdata/
 				Uri:         "wrong",
 			}))
 
-		result, err := FetchFiles(k, repo)
+		result, err := FetchFiles(context.Background(), k, NewSource("s3", file.NewHandler()), repo)
 		require.ErrorContains(t, err, "checksum")
 		require.Nil(t, result)
 	})
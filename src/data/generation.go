@@ -0,0 +1,65 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ResolveGeneration returns year and month of the dump generation to load for given types.
+// With latest, it is the newest generation having every types with checksum.
+// With fallback, it walks back month by month from given year and month, until such generation is found.
+// Otherwise, given year and month are returned as is.
+func ResolveGeneration(repo Repository, types []string, year, month string, latest, fallback bool) (time.Time, error) {
+	var begin time.Time
+	if !latest {
+		var err error
+		if begin, err = time.Parse("200601", year+fmt.Sprintf("%02s", month)); err != nil {
+			return begin, errors.New("failed to parse y and m: " + year + "." + month)
+		}
+		if !fallback {
+			return begin, nil
+		}
+	}
+
+	items, err := repo.FindAll(Filter{Types: types})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(items) == 0 {
+		return time.Time{}, fmt.Errorf("no data found for %+v", types)
+	}
+
+	// items are ordered by newest generation
+	earliest := toMonth(items[len(items)-1].GeneratedAt)
+	current := toMonth(items[0].GeneratedAt)
+	if !latest && begin.Before(current) {
+		current = begin
+	}
+	for ; !current.Before(earliest); current = current.AddDate(0, -1, 0) {
+		if isComplete(items, types, current) {
+			return current, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no generation has every %+v with checksum", types)
+}
+
+// isComplete reports whether every types has an item with checksum generated within the month.
+func isComplete(items []*Data, types []string, month time.Time) bool {
+	found := make(map[string]bool)
+	for _, item := range items {
+		if len(item.Checksum) > 0 && toMonth(item.GeneratedAt).Equal(month) {
+			found[item.TargetType] = true
+		}
+	}
+	for _, typ := range types {
+		if !found[typ] {
+			return false
+		}
+	}
+	return true
+}
+
+func toMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,58 @@
+package data
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestResolveGeneration(t *testing.T) {
+	var (
+		jan  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		feb  = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+		mar  = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		repo = NewMemoryRepository()
+	)
+	_, err := repo.BatchInsert([]*Data{
+		{ETag: "a1", GeneratedAt: jan, TargetType: "artists", Checksum: "c"},
+		{ETag: "l1", GeneratedAt: jan, TargetType: "labels", Checksum: "c"},
+		{ETag: "a2", GeneratedAt: feb, TargetType: "artists", Checksum: "c"},
+		{ETag: "l2", GeneratedAt: feb, TargetType: "labels"},
+		{ETag: "a3", GeneratedAt: mar, TargetType: "artists", Checksum: "c"},
+	})
+	require.NoError(t, err)
+
+	t.Run("latest picks newest generation having every types with checksum", func(t *testing.T) {
+		gen, err := ResolveGeneration(repo, []string{"artists", "labels"}, "", "", true, false)
+		require.NoError(t, err)
+		require.Equal(t, jan, gen)
+
+		gen, err = ResolveGeneration(repo, []string{"artists"}, "", "", true, false)
+		require.NoError(t, err)
+		require.Equal(t, mar, gen)
+	})
+	t.Run("fallback walks back from given month", func(t *testing.T) {
+		gen, err := ResolveGeneration(repo, []string{"artists"}, "2024", "2", false, true)
+		require.NoError(t, err)
+		require.Equal(t, feb, gen)
+
+		gen, err = ResolveGeneration(repo, []string{"artists"}, "2024", "12", false, true)
+		require.NoError(t, err)
+		require.Equal(t, mar, gen)
+
+		gen, err = ResolveGeneration(repo, []string{"artists", "labels"}, "2024", "03", false, true)
+		require.NoError(t, err)
+		require.Equal(t, jan, gen)
+	})
+	t.Run("exact month is returned as is", func(t *testing.T) {
+		gen, err := ResolveGeneration(repo, []string{"artists"}, "2024", "05", false, false)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), gen)
+	})
+	t.Run("returns err when nothing is complete", func(t *testing.T) {
+		_, err := ResolveGeneration(repo, []string{"artists", "masters"}, "", "", true, false)
+		require.ErrorContains(t, err, "no generation")
+		_, err = ResolveGeneration(repo, []string{"artists"}, "2023", "12", false, true)
+		require.ErrorContains(t, err, "no generation")
+	})
+}
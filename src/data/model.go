@@ -6,8 +6,10 @@ type Data struct {
 	ETag        string `xml:"ETag" gorm:"column:etag"`
 	GeneratedAt time.Time
 	Checksum    string
-	TargetType  string `gorm:"column:target_type"`
-	Uri         string `xml:"Key"`
+	TargetType  string     `gorm:"column:target_type"`
+	Uri         string     `xml:"Key"`
+	Size        int64      `xml:"Size" gorm:"column:size"`
+	LoadedAt    *time.Time `gorm:"column:loaded_at"`
 }
 
 func (Data) TableName() string {
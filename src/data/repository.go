@@ -3,23 +3,80 @@ package data
 import (
 	"errors"
 	"fmt"
+	"github.com/state303/go-discogs/src/database"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
 type Repository interface {
 	BatchInsert([]*Data) (int, error)
 	FindByYearMonthType(year, month, typ string) (*Data, error)
+	// FindAll returns items matching the filter, ordered by newest generation then types.
+	FindAll(filter Filter) ([]*Data, error)
+	// MarkLoaded records the time an item of given etag is loaded into the database.
+	MarkLoaded(etag string, at time.Time) error
+}
+
+// Filter narrows down items of Repository.FindAll. Zero value matches every item.
+type Filter struct {
+	// Year of generation, such as 2024. Empty matches any.
+	Year string
+	// Types to match, such as artists. Empty matches any.
+	Types []string
+	// Latest keeps only the newest generation of each types.
+	Latest bool
+}
+
+func (f Filter) keepLatest(items []*Data) []*Data {
+	if !f.Latest {
+		return items
+	}
+	latest := make(map[string]time.Time)
+	for _, item := range items {
+		if t, ok := latest[item.TargetType]; !ok || item.GeneratedAt.After(t) {
+			latest[item.TargetType] = item.GeneratedAt
+		}
+	}
+	res := make([]*Data, 0)
+	for _, item := range items {
+		if item.GeneratedAt.Equal(latest[item.TargetType]) {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// Migrate adds columns missing from data table created by former versions.
+func Migrate(db *gorm.DB) error {
+	m := db.Table(database.TableName(Data{}.TableName())).Migrator()
+	if !m.HasTable(&Data{}) {
+		return nil
+	}
+	for _, column := range []string{"Size", "LoadedAt"} {
+		if m.HasColumn(&Data{}, column) {
+			continue
+		}
+		if err := m.AddColumn(&Data{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type repositoryImpl struct {
 	DB *gorm.DB
 }
 
+// BatchInsert inserts items, updating size of existing items of same etag as those listed before size was recorded.
 func (d *repositoryImpl) BatchInsert(items []*Data) (int, error) {
-	tx := d.DB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&items, len(items))
+	tx := d.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "etag"}},
+		DoUpdates: clause.AssignmentColumns([]string{"size"}),
+	}).CreateInBatches(&items, len(items))
 	return int(tx.RowsAffected), tx.Error
 }
 
@@ -43,6 +100,114 @@ func (d *repositoryImpl) FindByYearMonthType(y, m, t string) (*Data, error) {
 	return result, err
 }
 
+func (d *repositoryImpl) FindAll(filter Filter) ([]*Data, error) {
+	var items []*Data
+	tx := d.DB.Session(&gorm.Session{})
+	if len(filter.Year) > 0 {
+		begin, err := time.Parse("2006", filter.Year)
+		if err != nil {
+			return nil, errors.New("failed to parse y: " + filter.Year)
+		}
+		tx = tx.Where("generated_at >= ? AND generated_at < ?", begin, begin.AddDate(1, 0, 0))
+	}
+	if len(filter.Types) > 0 {
+		tx = tx.Where("target_type IN ?", filter.Types)
+	}
+	if err := tx.Order("generated_at DESC, target_type").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return filter.keepLatest(items), nil
+}
+
+func (d *repositoryImpl) MarkLoaded(etag string, at time.Time) error {
+	return d.DB.Session(&gorm.Session{}).Model(&Data{}).Where("etag = ?", etag).Update("loaded_at", at).Error
+}
+
 func NewDataRepository(db *gorm.DB) Repository {
 	return &repositoryImpl{db}
 }
+
+// NewMemoryRepository returns Repository that keeps items in memory, such as a listing of Source without database.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{items: make([]*Data, 0)}
+}
+
+type memoryRepository struct {
+	mu    sync.RWMutex
+	items []*Data
+}
+
+func (m *memoryRepository) BatchInsert(items []*Data) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+Items:
+	for _, item := range items {
+		for _, existing := range m.items {
+			if existing.ETag == item.ETag {
+				existing.Size = item.Size
+				continue Items
+			}
+		}
+		m.items = append(m.items, item)
+	}
+	return len(items), nil
+}
+
+func (m *memoryRepository) FindByYearMonthType(y, mo, t string) (*Data, error) {
+	begin, err := time.Parse("20060102", y+mo+"01")
+	if err != nil {
+		return nil, errors.New("failed to parse y and m: " + y + "." + mo)
+	}
+	end := begin.AddDate(0, 1, 0)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, item := range m.items {
+		if item.TargetType == t && !item.GeneratedAt.Before(begin) && item.GeneratedAt.Before(end) {
+			return item, nil
+		}
+	}
+	return nil, fmt.Errorf("%+v data not found from y:%+v m:%+v", t, y, mo)
+}
+
+func (m *memoryRepository) FindAll(filter Filter) ([]*Data, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items := make([]*Data, 0)
+	for _, item := range m.items {
+		if len(filter.Year) > 0 && item.GeneratedAt.Format("2006") != filter.Year {
+			continue
+		}
+		if len(filter.Types) > 0 && !containsStr(filter.Types, item.TargetType) {
+			continue
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].GeneratedAt.Equal(items[j].GeneratedAt) {
+			return items[i].GeneratedAt.After(items[j].GeneratedAt)
+		}
+		return items[i].TargetType < items[j].TargetType
+	})
+	return filter.keepLatest(items), nil
+}
+
+func (m *memoryRepository) MarkLoaded(etag string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, item := range m.items {
+		if item.ETag == etag {
+			item.LoadedAt = &at
+		}
+	}
+	return nil
+}
+
+func containsStr(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -67,10 +67,15 @@ func (s *DataRepoSuite) TestBatchInsert() {
 	require.Equal(s.T(), typ, md.TargetType)
 	require.Equal(s.T(), uri, md.Uri)
 
-	count, err = s.repo.BatchInsert(d)
+	// relisting backfills size of the existing item
+	d[0].Size = 42
+	_, err = s.repo.BatchInsert(d)
 	require.NoError(s.T(), err)
-	require.Equal(s.T(), 0, count)
+	var rows int64
+	s.DB.Model(&Data{}).Where("etag = ?", etag).Count(&rows)
+	require.Equal(s.T(), int64(1), rows)
 	s.DB.First(&md)
+	require.Equal(s.T(), int64(42), md.Size)
 	require.Equal(s.T(), etag, md.ETag)
 	require.Equal(s.T(), gen.Format("20060102"), md.GeneratedAt.Format("20060102"))
 	require.Equal(s.T(), chk, md.Checksum)
@@ -80,3 +85,66 @@ func (s *DataRepoSuite) TestBatchInsert() {
 	s.DB.Where("etag = ?", etag).Delete(&md)
 	assert.ErrorContains(s.T(), s.DB.First(&md).Error, "record not found")
 }
+
+func (s *DataRepoSuite) TestFindAllThenMarkLoaded() {
+	s.Prepare()
+	var (
+		older = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+		newer = time.Date(2001, 2, 1, 0, 0, 0, 0, time.UTC)
+	)
+	d := []*Data{
+		{ETag: "test-find-all-1", GeneratedAt: older, Checksum: "c", TargetType: "artists", Uri: "u1", Size: 1},
+		{ETag: "test-find-all-2", GeneratedAt: newer, Checksum: "c", TargetType: "artists", Uri: "u2", Size: 2},
+		{ETag: "test-find-all-3", GeneratedAt: newer, Checksum: "c", TargetType: "labels", Uri: "u3", Size: 3},
+	}
+	_, err := s.repo.BatchInsert(d)
+	require.NoError(s.T(), err)
+	defer s.DB.Where("etag LIKE ?", "test-find-all-%").Delete(&Data{})
+
+	items, err := s.repo.FindAll(Filter{Year: "2001", Types: []string{"artists"}})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), items, 2)
+	require.Equal(s.T(), "test-find-all-2", items[0].ETag)
+
+	items, err = s.repo.FindAll(Filter{Year: "2001", Latest: true})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), items, 2)
+
+	require.NoError(s.T(), s.repo.MarkLoaded("test-find-all-3", newer))
+	items, err = s.repo.FindAll(Filter{Year: "2001", Types: []string{"labels"}})
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), items[0].LoadedAt)
+}
+
+func TestMemoryRepository(t *testing.T) {
+	repo := NewMemoryRepository()
+	gen := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	n, err := repo.BatchInsert([]*Data{
+		{ETag: "a", GeneratedAt: gen, TargetType: "artists"},
+		{ETag: "l", GeneratedAt: gen, TargetType: "labels"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	t.Run("finds by year month and types", func(t *testing.T) {
+		d, err := repo.FindByYearMonthType("2024", "03", "labels")
+		require.NoError(t, err)
+		require.Equal(t, "l", d.ETag)
+	})
+	t.Run("returns err when not found", func(t *testing.T) {
+		_, err := repo.FindByYearMonthType("2024", "04", "labels")
+		require.ErrorContains(t, err, "not found")
+	})
+	t.Run("returns err when invalid year month", func(t *testing.T) {
+		_, err := repo.FindByYearMonthType("xxxx", "xx", "labels")
+		require.ErrorContains(t, err, "failed to parse")
+	})
+	t.Run("relisting backfills size", func(t *testing.T) {
+		_, err := repo.BatchInsert([]*Data{{ETag: "a", GeneratedAt: gen, TargetType: "artists", Size: 42}})
+		require.NoError(t, err)
+		items, err := repo.FindAll(Filter{Types: []string{"artists"}})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		require.Equal(t, int64(42), items[0].Size)
+	})
+}
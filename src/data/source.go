@@ -0,0 +1,252 @@
+package data
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/reactivex/rxgo/v2"
+	"github.com/state303/go-discogs/src/file"
+	"github.com/state303/go-discogs/src/helper"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var dumpFilenamePattern = regexp.MustCompile(`^discogs_(\d{8})_(\w+)\.(.*)$`)
+
+// Source lists dump data and places their files into local file system.
+type Source interface {
+	// List returns every dump data this source holds, with checksum populated if known.
+	List(ctx context.Context) ([]*Data, error)
+	// Fetch places dump file of given data under dir while validating its checksum, then returns its local path.
+	// Download stops once ctx is done.
+	Fetch(ctx context.Context, d *Data, dir string) (string, error)
+}
+
+// NewSource returns Source by given source option, placing files with given file.Handler.
+// Empty or "s3" refers to discogs S3 bucket, http(s) url refers to a mirror with S3 compatible listing,
+// and anything else is treated as a local directory (optionally prefixed by file://) which never touches network.
+func NewSource(source string, handler file.Handler) Source {
+	if dir, ok := LocalDir(source); ok {
+		return NewDirSource(dir, handler)
+	}
+	if len(source) == 0 || source == "s3" {
+		return NewHttpSource(DiscogsS3BaseUrl, handler)
+	}
+	return NewHttpSource(source, handler)
+}
+
+// LocalDir returns directory of given source, if the source is a local directory rather than a remote url.
+func LocalDir(source string) (string, bool) {
+	if len(source) == 0 || source == "s3" || strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return "", false
+	}
+	return strings.TrimPrefix(source, "file://"), true
+}
+
+// NewHttpSource returns Source that lists and downloads from given base url, laid out as discogs S3 bucket.
+func NewHttpSource(baseUrl string, handler file.Handler) Source {
+	if !strings.HasSuffix(baseUrl, "/") {
+		baseUrl += "/"
+	}
+	return &httpSource{baseUrl: baseUrl, handler: handler}
+}
+
+type httpSource struct {
+	baseUrl string
+	handler file.Handler
+}
+
+func (h *httpSource) List(ctx context.Context) ([]*Data, error) {
+	listed, err := h.listAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := rxgo.Just(listed)().
+		Filter(NotNilFilter()).
+		Filter(ValidUriFilter()).
+		Map(PopulateFromUri()).
+		Map(DispatchChecksumFetch(h.baseUrl), rxgo.WithCPUPool()). // NOT ordered
+		ToSlice(len(listed), rxgo.WithContext(ctx))
+
+	if err != nil {
+		return nil, err
+	}
+
+	// wait until checksum fetch is complete
+	wgSig := make(chan struct{}, 1)
+	go func() {
+		defer close(wgSig)
+		checksumFetchWg.Wait()
+		wgSig <- struct{}{}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-wgSig:
+		break
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	res := make([]*Data, 0, len(items))
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		v, _ := SetChecksumValues(checksumMap)(ctx, item)
+		res = append(res, v.(*Data))
+	}
+	return res, nil
+}
+
+// listAll follows markers of the bucket listing until it is no longer truncated.
+// S3 only reports NextMarker along with a delimiter, hence the last key of the page continues otherwise.
+func (h *httpSource) listAll(ctx context.Context) ([]*Data, error) {
+	var (
+		items  = make([]*Data, 0)
+		marker = ""
+	)
+	for {
+		page, err := h.listPage(ctx, marker)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Contents...)
+		if !page.IsTruncated || len(page.Contents) == 0 {
+			return items, nil
+		}
+		next := page.NextMarker
+		if len(next) == 0 {
+			next = page.Contents[len(page.Contents)-1].Uri
+		}
+		if next == marker {
+			return nil, fmt.Errorf("bucket listing stuck at marker %+v", marker)
+		}
+		marker = next
+	}
+}
+
+func (h *httpSource) listPage(ctx context.Context, marker string) (*listBucketResult, error) {
+	uri := h.baseUrl
+	if len(marker) > 0 {
+		uri += "?marker=" + url.QueryEscape(marker)
+	}
+	item, ok := <-getClient().Get(ctx, uri).Observe()
+	if !ok {
+		return nil, fmt.Errorf("no response from %+v", uri)
+	} else if item.E != nil {
+		return nil, item.E
+	}
+	page := new(listBucketResult)
+	if err := xml.Unmarshal(item.V.([]byte), page); err != nil {
+		return nil, fmt.Errorf("failed to parse bucket listing from %+v: %w", uri, err)
+	}
+	return page, nil
+}
+
+func (h *httpSource) Fetch(ctx context.Context, d *Data, dir string) (string, error) {
+	targetPath := path.Join(dir, helper.GetLastUriSegment(d.Uri))
+	return targetPath, h.handler.FetchAndCheck(ctx, h.baseUrl+d.Uri, targetPath, d.Checksum)
+}
+
+// listBucketResult is a page of S3 ListObjects (v1) response.
+type listBucketResult struct {
+	XMLName     xml.Name `xml:"ListBucketResult"`
+	IsTruncated bool     `xml:"IsTruncated"`
+	NextMarker  string   `xml:"NextMarker"`
+	Contents    []*Data  `xml:"Contents"`
+}
+
+// NewDirSource returns Source that scans given directory for discogs_YYYYMMDD_<types>.xml.gz files
+// and their discogs_YYYYMMDD_CHECKSUM.txt. It never touches network.
+func NewDirSource(dir string, handler file.Handler) Source {
+	return &dirSource{dir: dir, handler: handler}
+}
+
+type dirSource struct {
+	dir     string
+	handler file.Handler
+}
+
+func (s *dirSource) List(_ context.Context) ([]*Data, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*Data, 0)
+	checksums := make(map[time.Time]map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), file.PartSuffix) {
+			continue
+		}
+		match := dumpFilenamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		gen, err := time.Parse("20060102", match[1])
+		if err != nil {
+			continue
+		}
+		typ := strings.ToLower(match[2])
+		if !isKnownType(typ) {
+			continue
+		}
+		if typ == "checksum" {
+			if err = s.readChecksum(entry.Name(), checksums); err != nil {
+				return nil, err
+			}
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &Data{
+			ETag:        entry.Name(),
+			GeneratedAt: gen,
+			TargetType:  typ,
+			Uri:         fmt.Sprintf("data/%+v/%+v", gen.Format("2006"), entry.Name()),
+			Size:        info.Size(),
+		})
+	}
+
+	for _, item := range items {
+		item.Checksum = checksums[item.GeneratedAt][item.TargetType]
+	}
+	return items, nil
+}
+
+func (s *dirSource) readChecksum(filename string, checksums map[time.Time]map[string]string) error {
+	b, err := s.handler.Read(path.Join(s.dir, filename))
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if c, ok := parseChecksumTextLine(line); ok {
+			if _, found := checksums[c.gen]; !found {
+				checksums[c.gen] = make(map[string]string)
+			}
+			checksums[c.gen][c.typ] = c.chk
+		}
+	}
+	return nil
+}
+
+func (s *dirSource) Fetch(_ context.Context, d *Data, _ string) (string, error) {
+	filepath := path.Join(s.dir, helper.GetLastUriSegment(d.Uri))
+	if found, err := s.handler.Exists(filepath); err != nil {
+		return "", err
+	} else if !found {
+		return "", fmt.Errorf("%+v not found from %+v", helper.GetLastUriSegment(d.Uri), s.dir)
+	}
+	if err := s.handler.Checksum(filepath, d.Checksum); err != nil {
+		return "", fmt.Errorf("%+v: %w", filepath, err)
+	}
+	return filepath, nil
+}
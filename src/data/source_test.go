@@ -0,0 +1,155 @@
+package data
+
+import (
+	"context"
+	"github.com/state303/go-discogs/internal/test/resource"
+	"github.com/state303/go-discogs/internal/testserver"
+	"github.com/state303/go-discogs/src/file"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+const fetchDataTestChecksum = "69718470e15145cf586db15389bb2bf81b4cf4ee179aa6c0dd61afaf17d56b3d"
+
+func TestNewSource(t *testing.T) {
+	t.Run("s3 by default", func(t *testing.T) {
+		for _, v := range []string{"", "s3"} {
+			src, ok := NewSource(v, file.NewHandler()).(*httpSource)
+			require.True(t, ok)
+			require.Equal(t, DiscogsS3BaseUrl, src.baseUrl)
+		}
+	})
+	t.Run("mirror by url", func(t *testing.T) {
+		src, ok := NewSource("https://mirror.local/discogs", file.NewHandler()).(*httpSource)
+		require.True(t, ok)
+		require.Equal(t, "https://mirror.local/discogs/", src.baseUrl)
+	})
+	t.Run("directory otherwise", func(t *testing.T) {
+		src, ok := NewSource("file:///var/discogs", file.NewHandler()).(*dirSource)
+		require.True(t, ok)
+		require.Equal(t, "/var/discogs", src.dir)
+		_, ok = NewSource("dumps", file.NewHandler()).(*dirSource)
+		require.True(t, ok)
+	})
+}
+
+func prepareDumpDir(t *testing.T) string {
+	dir := t.TempDir()
+	content := resource.Read("testdata/fetch-data-test.xml")
+	require.NoError(t, os.WriteFile(path.Join(dir, "discogs_20101001_artists.xml.gz"), content, 0644))
+	require.NoError(t, os.WriteFile(path.Join(dir, "discogs_20101001_labels.xml.gz"), []byte("corrupted"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(dir, "discogs_20101001_CHECKSUM.txt"), []byte(
+		fetchDataTestChecksum+" *discogs_20101001_artists.xml.gz\n"+
+			fetchDataTestChecksum+" *discogs_20101001_labels.xml.gz\n"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(dir, "discogs_20101001_masters.xml.gz.part"), []byte("partial"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(dir, "notes.txt"), []byte("ignored"), 0644))
+	return dir
+}
+
+func TestDirSource(t *testing.T) {
+	dir := prepareDumpDir(t)
+	src := NewDirSource(dir, file.NewHandler())
+
+	t.Run("list dump files with checksum", func(t *testing.T) {
+		items, err := src.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+		for _, item := range items {
+			require.Equal(t, time.Date(2010, 10, 1, 0, 0, 0, 0, time.UTC), item.GeneratedAt)
+			require.Contains(t, item.Uri, "data/2010/discogs_20101001_")
+			require.NotEmpty(t, item.ETag)
+			if item.TargetType != "checksum" {
+				require.Equal(t, fetchDataTestChecksum, item.Checksum)
+			}
+		}
+	})
+
+	t.Run("fetch returns path within directory", func(t *testing.T) {
+		p, err := src.Fetch(context.Background(), &Data{Uri: "data/2010/discogs_20101001_artists.xml.gz", Checksum: fetchDataTestChecksum}, "unused")
+		require.NoError(t, err)
+		require.Equal(t, path.Join(dir, "discogs_20101001_artists.xml.gz"), p)
+	})
+
+	t.Run("fetch fails on checksum mismatch", func(t *testing.T) {
+		_, err := src.Fetch(context.Background(), &Data{Uri: "data/2010/discogs_20101001_labels.xml.gz", Checksum: fetchDataTestChecksum}, "unused")
+		require.ErrorContains(t, err, "checksum")
+	})
+
+	t.Run("fetch fails on missing file", func(t *testing.T) {
+		_, err := src.Fetch(context.Background(), &Data{Uri: "data/2010/discogs_20101001_masters.xml.gz"}, "unused")
+		require.ErrorContains(t, err, "not found")
+	})
+}
+
+func TestHttpSourceFetchFromMirror(t *testing.T) {
+	server := testserver.NewServer(func(requests []*testserver.HttpRequest, w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/mirror/data/2010/discogs_20101001_artists.xml.gz" {
+			_, _ = w.Write(resource.Read("testdata/fetch-data-test.xml"))
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer server.Close()
+
+	dir := t.TempDir()
+	src := NewSource(server.URL+"/mirror", file.NewHandler())
+	p, err := src.Fetch(context.Background(), &Data{Uri: "data/2010/discogs_20101001_artists.xml.gz", Checksum: fetchDataTestChecksum}, dir)
+	require.NoError(t, err)
+	require.Equal(t, path.Join(dir, "discogs_20101001_artists.xml.gz"), p)
+	require.Len(t, server.Requests(), 1)
+}
+
+func TestHttpSourceListPaginated(t *testing.T) {
+	objects := make(map[string]string)
+	for m := 1; m <= 12; m++ {
+		gen := time.Date(2023, time.Month(m), 1, 0, 0, 0, 0, time.UTC).Format("20060102")
+		checksum := ""
+		for _, typ := range []string{"artists", "labels", "masters", "releases"} {
+			filename := "discogs_" + gen + "_" + typ + ".xml.gz"
+			objects["data/2023/"+filename] = typ
+			checksum += fetchDataTestChecksum + " *" + filename + "\n"
+		}
+		objects["data/2023/discogs_"+gen+"_CHECKSUM.txt"] = checksum
+	}
+	objects["index.html"] = "not a dump"
+
+	server := testserver.NewBucketServer(objects, 7)
+	defer server.Close()
+
+	repo := &RepositoryStub{items: make([]*Data, 0)}
+	count, err := UpdateData(context.Background(), NewSource(server.URL, file.NewHandler()), repo)
+	require.NoError(t, err)
+	require.Equal(t, 60, count)
+
+	listRequests := 0
+	for _, r := range server.Requests() {
+		if r.URL.Path == "/" {
+			listRequests++
+		}
+	}
+	require.Equal(t, 9, listRequests) // 61 keys by 7 per page
+
+	latest := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	found := 0
+	for _, item := range repo.items {
+		if item.TargetType != "checksum" {
+			require.Equal(t, fetchDataTestChecksum, item.Checksum)
+		}
+		if item.GeneratedAt.Equal(latest) {
+			found++
+		}
+	}
+	require.Equal(t, 5, found)
+}
+
+func TestHttpSourceListFailsOnInvalidListing(t *testing.T) {
+	server := testserver.NewServerWithStaticResponse("<Error><Code>AccessDenied</Code></Error>")
+	defer server.Close()
+
+	_, err := NewSource(server.URL, file.NewHandler()).List(context.Background())
+	require.ErrorContains(t, err, "failed to parse bucket listing")
+}
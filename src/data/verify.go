@@ -0,0 +1,83 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"github.com/state303/go-discogs/src/file"
+	"github.com/state303/go-discogs/src/helper"
+	"os"
+	"path"
+	"sort"
+)
+
+const (
+	VerifyOK      = "OK"
+	VerifyFailed  = "FAILED"
+	VerifyUnknown = "UNKNOWN"
+)
+
+// Verification reports checksum result of a local dump file.
+type Verification struct {
+	Path     string
+	Checksum string
+	Err      error
+}
+
+// Status returns VerifyUnknown if no checksum was found, VerifyFailed on error, otherwise VerifyOK.
+func (v *Verification) Status() string {
+	if v.Err != nil {
+		return VerifyFailed
+	} else if len(v.Checksum) == 0 {
+		return VerifyUnknown
+	}
+	return VerifyOK
+}
+
+// VerifyFiles checks each dump file, or dump files within each directory, against the CHECKSUM file next to them.
+// Files without such checksum are looked up from given repo, which may be nil.
+func VerifyFiles(ctx context.Context, paths []string, handler file.Handler, repo Repository) ([]*Verification, error) {
+	targets := make(map[string]*Data)
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		dir, name := p, ""
+		if !info.IsDir() {
+			dir, name = path.Dir(p), path.Base(p)
+		}
+		items, err := NewDirSource(dir, handler).List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, item := range items {
+			filename := helper.GetLastUriSegment(item.Uri)
+			if item.TargetType == "checksum" || (len(name) > 0 && filename != name) {
+				continue
+			}
+			found = true
+			targets[path.Join(dir, filename)] = item
+		}
+		if !found && len(name) > 0 {
+			return nil, errors.New("not a dump file: " + p)
+		}
+	}
+
+	res := make([]*Verification, 0, len(targets))
+	for p, item := range targets {
+		if len(item.Checksum) == 0 && repo != nil {
+			y, m := item.GeneratedAt.Format("2006"), item.GeneratedAt.Format("01")
+			if stored, err := repo.FindByYearMonthType(y, m, item.TargetType); err == nil {
+				item.Checksum = stored.Checksum
+			}
+		}
+		v := &Verification{Path: p, Checksum: item.Checksum}
+		if len(item.Checksum) > 0 {
+			v.Err = handler.Checksum(p, item.Checksum)
+		}
+		res = append(res, v)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Path < res[j].Path })
+	return res, nil
+}
@@ -0,0 +1,56 @@
+package data
+
+import (
+	"context"
+	"github.com/state303/go-discogs/src/file"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestVerifyFiles(t *testing.T) {
+	dir := prepareDumpDir(t)
+	h := file.NewHandler()
+
+	t.Run("verifies every dump file within directory", func(t *testing.T) {
+		res, err := VerifyFiles(context.Background(), []string{dir}, h, nil)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		require.Equal(t, path.Join(dir, "discogs_20101001_artists.xml.gz"), res[0].Path)
+		require.Equal(t, VerifyOK, res[0].Status())
+		require.Equal(t, VerifyFailed, res[1].Status())
+	})
+
+	t.Run("verifies single file", func(t *testing.T) {
+		res, err := VerifyFiles(context.Background(), []string{path.Join(dir, "discogs_20101001_artists.xml.gz")}, h, nil)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, VerifyOK, res[0].Status())
+	})
+
+	t.Run("returns err when not a dump file", func(t *testing.T) {
+		_, err := VerifyFiles(context.Background(), []string{path.Join(dir, "notes.txt")}, h, nil)
+		require.ErrorContains(t, err, "not a dump file")
+	})
+
+	t.Run("looks up repository when checksum file is missing", func(t *testing.T) {
+		p := path.Join(dir, "discogs_20101001_artists.xml.gz")
+		other := t.TempDir()
+		b, err := os.ReadFile(p)
+		require.NoError(t, err)
+		target := path.Join(other, "discogs_20101001_artists.xml.gz")
+		require.NoError(t, os.WriteFile(target, b, 0644))
+
+		res, err := VerifyFiles(context.Background(), []string{target}, h, nil)
+		require.NoError(t, err)
+		require.Equal(t, VerifyUnknown, res[0].Status())
+
+		repo := NewMemoryRepository()
+		_, _ = repo.BatchInsert([]*Data{{GeneratedAt: time.Date(2010, 10, 1, 0, 0, 0, 0, time.UTC), TargetType: "artists", Checksum: fetchDataTestChecksum}})
+		res, err = VerifyFiles(context.Background(), []string{target}, h, repo)
+		require.NoError(t, err)
+		require.Equal(t, VerifyOK, res[0].Status())
+	})
+}
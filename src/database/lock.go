@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// lockPollInterval is the delay between attempts while waiting for a lock held by another run.
+const lockPollInterval = 500 * time.Millisecond
+
+// ErrLocked is returned when the lock is held by another run beyond the timeout.
+var ErrLocked = errors.New("lock is held by another run")
+
+// Lock is a database-level lock, an advisory lock of Postgres or GET_LOCK of MySQL.
+// The lock belongs to a session, hence it is held by a dedicated connection until released.
+type Lock struct {
+	conn *sql.Conn
+	name string
+}
+
+// AcquireLock takes the lock of given name. It fails fast with ErrLocked if timeout is zero,
+// otherwise waits until timeout for another run to release it.
+func AcquireLock(ctx context.Context, db *gorm.DB, name string, timeout time.Duration) (*Lock, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := tryLock(ctx, conn, name)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		} else if ok {
+			return &Lock{conn: conn, name: name}, nil
+		}
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed to lock %+v: %w", name, ErrLocked)
+		} else if wait > lockPollInterval {
+			wait = lockPollInterval
+		}
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func tryLock(ctx context.Context, conn *sql.Conn, name string) (ok bool, err error) {
+	switch Kind {
+	case MySQL:
+		err = conn.QueryRowContext(ctx, "SELECT COALESCE(GET_LOCK(?, 0), 0) = 1", name).Scan(&ok)
+	default:
+		err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", name).Scan(&ok)
+	}
+	return
+}
+
+// Release releases the lock, then returns its connection to the pool.
+func (l *Lock) Release() error {
+	var err error
+	switch Kind {
+	case MySQL:
+		_, err = l.conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", l.name)
+	default:
+		_, err = l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", l.name)
+	}
+	if closeErr := l.conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Name returns name of the lock.
+func (l *Lock) Name() string {
+	return l.name
+}
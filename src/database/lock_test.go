@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"github.com/state303/go-discogs/internal/testutils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock(t *testing.T) {
+	pg := testutils.GetDatabase(testutils.Postgres)
+	dsn := testutils.GetDsn(testutils.Postgres, pg)
+	require.NoError(t, Connect(dsn))
+
+	lock, err := AcquireLock(context.Background(), DB, "go-discogs/public", 0)
+	require.NoError(t, err)
+
+	_, err = AcquireLock(context.Background(), DB, "go-discogs/public", 0)
+	require.ErrorIs(t, err, ErrLocked)
+
+	begin := time.Now()
+	_, err = AcquireLock(context.Background(), DB, "go-discogs/public", time.Second)
+	require.ErrorIs(t, err, ErrLocked)
+	require.GreaterOrEqual(t, time.Since(begin), time.Second)
+
+	other, err := AcquireLock(context.Background(), DB, "go-discogs/other", 0)
+	require.NoError(t, err)
+	require.NoError(t, other.Release())
+
+	require.NoError(t, lock.Release())
+	lock, err = AcquireLock(context.Background(), DB, "go-discogs/public", 0)
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Naming places tables into a schema with a prefix, so that they do not clash with tables of a shared database.
+// Generated models keep their TableName, which is prefixed by callbacks on every statement instead.
+type Naming struct {
+	// Schema is set as search_path of connections, and created if missing. Empty uses the default of the database.
+	Schema string
+	// TablePrefix is prepended to every table, index and constraint name.
+	TablePrefix string
+}
+
+// Names is the Naming applied by Connect and GetConnect.
+var Names Naming
+
+// ddlIdentifierPattern matches quoted names of tables, indexes and constraints following their keyword in the DDL script.
+var ddlIdentifierPattern = regexp.MustCompile(`(?i)\b(TABLE|INDEX|ON|COLUMN|REFERENCES|CONSTRAINT)(\s+)"(\w+)"`)
+
+// TableName returns name of table with the prefix of Names.
+func TableName(table string) string {
+	return Names.TableName(table)
+}
+
+// TableName returns name of table with the prefix.
+func (n Naming) TableName(table string) string {
+	return n.TablePrefix + table
+}
+
+// RewriteDDL prefixes names of tables, indexes and constraints in the DDL script.
+func (n Naming) RewriteDDL(sql string) string {
+	if len(n.TablePrefix) == 0 {
+		return sql
+	}
+	return ddlIdentifierPattern.ReplaceAllString(sql, `$1$2"`+n.TablePrefix+`$3"`)
+}
+
+// register adds callbacks prefixing table of statements parsed from models.
+// Table set explicitly, such as by db.Table, is left as is.
+func (n Naming) register(db *gorm.DB) error {
+	if len(n.TablePrefix) == 0 {
+		return nil
+	}
+	const callbackName = "discogs:table_prefix"
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register(callbackName, n.prefixTable); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register(callbackName, n.prefixTable); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register(callbackName, n.prefixTable); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register(callbackName, n.prefixTable); err != nil {
+		return err
+	}
+	return cb.Row().Before("gorm:row").Register(callbackName, n.prefixTable)
+}
+
+func (n Naming) prefixTable(db *gorm.DB) {
+	if s := db.Statement.Schema; s != nil && db.Statement.Table == s.Table {
+		db.Statement.Table = n.TableName(s.Table)
+	}
+}
+
+// createSchema creates the schema of Naming if missing.
+func (n Naming) createSchema(db *gorm.DB) error {
+	if len(n.Schema) == 0 {
+		return nil
+	}
+	return db.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %+v", QuoteIdentifier(n.Schema))).Error
+}
+
+// WithSearchPath returns dsn connecting with search_path of schema, either of url or key=value form.
+func WithSearchPath(dsn, schema string) string {
+	if u, err := url.Parse(dsn); err == nil && len(u.Scheme) > 0 {
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return strings.TrimSpace(dsn) + " search_path=" + schema
+}
+
+// QuoteIdentifier quotes identifier for raw statements.
+func QuoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestWithSearchPath(t *testing.T) {
+	require.Equal(t, "postgres://u:p@localhost:5432/db?search_path=public_shadow&sslmode=disable",
+		WithSearchPath("postgres://u:p@localhost:5432/db?sslmode=disable", "public_shadow"))
+	require.Equal(t, "host=localhost user=u password=p dbname=db port=5432 search_path=public_shadow",
+		WithSearchPath("host=localhost user=u password=p dbname=db port=5432 ", "public_shadow"))
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	require.Equal(t, `"public_shadow"`, QuoteIdentifier("public_shadow"))
+	require.Equal(t, `"a""b"`, QuoteIdentifier(`a"b`))
+}
+
+func TestNamingRewriteDDL(t *testing.T) {
+	sql := `CREATE TABLE "style" ("id" SERIAL PRIMARY KEY NOT NULL, "name" VARCHAR(50) UNIQUE NOT NULL);
+CREATE INDEX "pk_style" ON "style" ("id");
+COMMENT ON TABLE "data" IS 'data dumps';
+COMMENT ON COLUMN "style"."name" IS 'name of style';
+ALTER TABLE "release" ADD CONSTRAINT "fk_release_master" FOREIGN KEY ("master_id") REFERENCES "master" ("id");`
+
+	require.Equal(t, sql, Naming{}.RewriteDDL(sql))
+	require.Equal(t, `CREATE TABLE "dg_style" ("id" SERIAL PRIMARY KEY NOT NULL, "name" VARCHAR(50) UNIQUE NOT NULL);
+CREATE INDEX "dg_pk_style" ON "dg_style" ("id");
+COMMENT ON TABLE "dg_data" IS 'data dumps';
+COMMENT ON COLUMN "dg_style"."name" IS 'name of style';
+ALTER TABLE "dg_release" ADD CONSTRAINT "dg_fk_release_master" FOREIGN KEY ("master_id") REFERENCES "dg_master" ("id");`,
+		Naming{TablePrefix: "dg_"}.RewriteDDL(sql))
+}
+
+type style struct {
+	ID   int32
+	Name string
+}
+
+func (*style) TableName() string {
+	return "style"
+}
+
+func TestNamingPrefixesTable(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	require.NoError(t, Naming{TablePrefix: "dg_"}.register(db))
+
+	tx := db.Create(&style{ID: 1, Name: "Jazz"})
+	require.NoError(t, tx.Error)
+	stmt := tx.Statement
+	require.Contains(t, stmt.SQL.String(), `INSERT INTO "dg_style"`)
+
+	stmt = db.Where("name = ?", "Jazz").Find(&[]*style{}).Statement
+	require.Contains(t, stmt.SQL.String(), `FROM "dg_style"`)
+
+	stmt = db.Model(&style{ID: 1}).Update("name", "Rock").Statement
+	require.Contains(t, stmt.SQL.String(), `UPDATE "dg_style"`)
+
+	stmt = db.Table("other").Find(&[]*style{}).Statement
+	require.Contains(t, stmt.SQL.String(), `FROM "other"`)
+}
@@ -0,0 +1,99 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"github.com/state303/go-discogs/src/database"
+	"gorm.io/gorm"
+	"os"
+	"sync"
+	"time"
+)
+
+// TableDestination writes records into dead_letter table instead of a file.
+const TableDestination = "table"
+
+// Record is an element or a row failed to load, along with its error.
+type Record struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement" json:"-"`
+	Topic     string    `gorm:"column:topic" json:"topic"`
+	SourceID  string    `gorm:"column:source_id" json:"source_id"`
+	Error     string    `gorm:"column:error" json:"error"`
+	Payload   string    `gorm:"column:payload" json:"payload,omitempty"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (Record) TableName() string {
+	return "dead_letter"
+}
+
+// Sink stores dead-letter records. It is safe for concurrent use.
+type Sink interface {
+	Write(r *Record) error
+	Close() error
+}
+
+// NewSink returns Sink of dead_letter table if dest is TableDestination, otherwise of a file at dest.
+func NewSink(dest string, db *gorm.DB) (Sink, error) {
+	if dest == TableDestination {
+		return NewTableSink(db)
+	}
+	return NewFileSink(dest), nil
+}
+
+// NewFileSink returns Sink appending records as JSON lines. The file is created on the first record.
+func NewFileSink(path string) Sink {
+	return &fileSink{path: path}
+}
+
+type fileSink struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	enc  *json.Encoder
+}
+
+func (s *fileSink) Write(r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		s.f, s.enc = f, json.NewEncoder(f)
+	}
+	return s.enc.Encode(r)
+}
+
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+// NewTableSink returns Sink inserting records into dead_letter table, which is created if missing.
+func NewTableSink(db *gorm.DB) (Sink, error) {
+	if m := db.Table(database.TableName(Record{}.TableName())).Migrator(); !m.HasTable(&Record{}) {
+		if err := m.CreateTable(&Record{}); err != nil {
+			return nil, err
+		}
+	}
+	return &tableSink{db: db}, nil
+}
+
+type tableSink struct {
+	db *gorm.DB
+}
+
+func (s *tableSink) Write(r *Record) error {
+	return s.db.Session(&gorm.Session{}).Create(r).Error
+}
+
+func (s *tableSink) Close() error {
+	return nil
+}
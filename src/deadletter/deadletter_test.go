@@ -0,0 +1,42 @@
+package deadletter
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path"
+	"sync"
+	"testing"
+)
+
+func TestFileSink(t *testing.T) {
+	p := path.Join(t.TempDir(), "deadletter.jsonl")
+	sink, err := NewSink(p, nil)
+	require.NoError(t, err)
+	require.NoFileExists(t, p)
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, sink.Write(&Record{Topic: "artists", SourceID: "1", Error: "failed"}))
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, sink.Close())
+
+	f, err := os.Open(p)
+	require.NoError(t, err)
+	defer f.Close()
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		require.Equal(t, "artists", r.Topic)
+		lines++
+	}
+	require.Equal(t, 10, lines)
+}
@@ -0,0 +1,59 @@
+// Package decompress detects compression of dumps from their magic bytes, shared by the batch and pkg/discogs.
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"github.com/klauspost/pgzip"
+	"io"
+)
+
+// Gzip implementations to decompress gzip compressed dumps with.
+const (
+	// GzipStandard decompresses on a single goroutine with compress/gzip.
+	GzipStandard = "standard"
+	// GzipParallel decompresses with klauspost/pgzip, which reads ahead and checks blocks on goroutines of its own.
+	GzipParallel = "parallel"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ValidGzip returns error if gzip is none of known gzip implementations. Empty gzip is GzipStandard.
+func ValidGzip(gzip string) error {
+	switch gzip {
+	case GzipStandard, GzipParallel, "":
+		return nil
+	}
+	return fmt.Errorf("unknown gzip implementation: %+v", gzip)
+}
+
+// Reader returns r decompressed by the format detected from its magic bytes, either gzip or zstd.
+// Plain XML is returned as is. Gzip is decompressed with gzipImpl, where empty gzipImpl is GzipStandard.
+// Closing the returned reader releases the decompressor, though does not close r.
+func Reader(r io.Reader, gzipImpl string) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic) && gzipImpl == GzipParallel:
+		return pgzip.NewReader(br)
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, zstdMagic):
+		d, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return io.NopCloser(br), nil
+}
@@ -0,0 +1,65 @@
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	plain := bytes.Repeat([]byte("<labels><label><id>1</id><name>Planet E</name></label></labels>\n"), 1000)
+	gzipped, zstded := new(bytes.Buffer), new(bytes.Buffer)
+	gw := gzip.NewWriter(gzipped)
+	_, err := gw.Write(plain)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	zw, err := zstd.NewWriter(zstded)
+	require.NoError(t, err)
+	_, err = zw.Write(plain)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	tests := []struct {
+		name     string
+		src      []byte
+		gzipImpl string
+	}{
+		{"gzip standard", gzipped.Bytes(), GzipStandard},
+		{"gzip parallel", gzipped.Bytes(), GzipParallel},
+		{"gzip default", gzipped.Bytes(), ""},
+		{"zstd", zstded.Bytes(), GzipStandard},
+		{"plain", plain, GzipStandard},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Reader(bytes.NewReader(tt.src), tt.gzipImpl)
+			require.NoError(t, err)
+			b, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			require.Equal(t, plain, b)
+		})
+	}
+
+	t.Run("reads empty source as plain", func(t *testing.T) {
+		r, err := Reader(bytes.NewReader(nil), GzipStandard)
+		require.NoError(t, err)
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Empty(t, b)
+	})
+	t.Run("fails on broken gzip header", func(t *testing.T) {
+		_, err := Reader(bytes.NewReader([]byte{0x1f, 0x8b, 0x00}), GzipStandard)
+		require.Error(t, err)
+	})
+}
+
+func TestValidGzip(t *testing.T) {
+	require.NoError(t, ValidGzip(GzipStandard))
+	require.NoError(t, ValidGzip(GzipParallel))
+	require.NoError(t, ValidGzip(""))
+	require.Error(t, ValidGzip("lz4"))
+}
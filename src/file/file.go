@@ -2,18 +2,28 @@ package file
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/cavaliergopher/grab/v3"
 	"github.com/schollz/progressbar/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/state303/go-discogs/src/logging"
+	"github.com/state303/go-discogs/src/metrics"
 	"github.com/state303/go-discogs/src/reader"
 	"io"
+	"net/http"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// maxBackoff caps delay between retries.
+const maxBackoff = time.Minute
+
 type Handler interface {
 	// Copy file from source to destination by chunks of 32KB.
 	Copy(srcPath, dstPath string, targetPerm os.FileMode) error
@@ -22,11 +32,11 @@ type Handler interface {
 	// Checksum validates a file from given path.
 	// Returns error only if it cannot read or locate the file, or the checksum failed.
 	Checksum(filepath string, checksum string) error
-	// Fetch will retrieve file from given uri
-	Fetch(uri string, filepath string) error
+	// Fetch will retrieve file from given uri, until ctx is done.
+	Fetch(ctx context.Context, uri string, filepath string) error
 	// FetchAndCheck calls Fetch to retrieve file, while checking the checksum to validate incoming file.
 	// The file will be deleted if checksum fails.
-	FetchAndCheck(uri string, filepath string, checksum string) error
+	FetchAndCheck(ctx context.Context, uri string, filepath string, checksum string) error
 	// Write writes byte array content to given filepath and permission.
 	Write(filepath string, content []byte, perm os.FileMode) error
 	// Read reads byte array content from given filepath. This works as identical delegation to os.ReadFile.
@@ -35,12 +45,37 @@ type Handler interface {
 	Exists(filepath string) (bool, error)
 }
 
+// PartSuffix marks a file still being downloaded. It is resumed by the next fetch, then renamed on success.
+const PartSuffix = ".part"
+
+// Options configures how the Handler fetches files. Zero value fetches once without limits.
+type Options struct {
+	// Retries is the number of retries after a failed download.
+	Retries int
+	// RetryBackoff is the delay before the first retry, doubled on each retry up to a minute.
+	RetryBackoff time.Duration
+	// MaxBandwidth limits bytes per second, shared among all downloads of the Handler. Zero means unlimited.
+	MaxBandwidth int64
+}
+
 func NewHandler() Handler {
-	return &handlerImpl{reader: &fileReaderImpl{}}
+	return NewHandlerWithOptions(Options{})
+}
+
+// NewHandlerWithOptions returns Handler that fetches files with retries and bandwidth limit of given Options.
+func NewHandlerWithOptions(opts Options) Handler {
+	h := &handlerImpl{reader: &fileReaderImpl{}, opts: opts}
+	if opts.MaxBandwidth > 0 {
+		h.limiter = newRateLimiter(opts.MaxBandwidth)
+	}
+	return h
 }
 
 type handlerImpl struct {
-	reader Reader
+	reader    Reader
+	opts      Options
+	limiter   grab.RateLimiter
+	downloads atomic.Int32 // number of downloads in progress, which draw progress bars only alone
 }
 
 type Reader interface {
@@ -127,14 +162,11 @@ func (h *handlerImpl) Checksum(filepath string, checksum string) error {
 	return nil
 }
 
-func (h *handlerImpl) Fetch(uri string, filepath string) error {
-	// grab request
-	req := h.newRequest(uri, filepath)
-	// request
-	return h.execGrabReq(req)
+func (h *handlerImpl) Fetch(ctx context.Context, uri string, filepath string) error {
+	return h.download(ctx, uri, filepath, nil)
 }
 
-func (h *handlerImpl) FetchAndCheck(uri string, filepath string, checksum string) error {
+func (h *handlerImpl) FetchAndCheck(ctx context.Context, uri string, filepath string, checksum string) error {
 	var (
 		sum      []byte
 		err      error
@@ -147,25 +179,97 @@ func (h *handlerImpl) FetchAndCheck(uri string, filepath string, checksum string
 	if err != nil {
 		return err
 	} else if found {
-		fmt.Printf("found %+v. testing checksum...\n", filename)
+		log := logrus.WithField(logging.FieldFile, filename)
+		log.Info("found file. testing checksum...")
 		if err = h.Checksum(filepath, checksum); err == nil {
-			fmt.Printf("%+v checksum done. skipping fetch\n", filename)
+			log.Info("checksum done. skipping fetch")
 			return nil
 		}
-		fmt.Printf("%+v failed checksum. deleting...\n", filename)
+		log.Warn("failed checksum. deleting...")
 		if err = h.Delete(filepath); err != nil {
 			return err
 		}
 	}
-	fmt.Printf("fetching %+v...\n", filename)
+	logrus.WithField(logging.FieldFile, filename).Info("fetching...")
 	// prepare checksum
 	if sum, err = h.getDecodedChecksum(checksum); err != nil {
 		return err
 	}
-	// grab request with checksum
-	req := h.newRequestWithChecksum(uri, filepath, sum)
-	// request
-	return h.execGrabReq(req)
+	return h.download(ctx, uri, filepath, sum)
+}
+
+// download fetches uri into a part file, retrying with backoff as configured.
+// Part file of a failed attempt remains to be resumed by ranged request, unless its checksum failed.
+// The part file is renamed to filepath once download is complete. Download stops once ctx is done.
+func (h *handlerImpl) download(ctx context.Context, uri, filepath string, checksum []byte) error {
+	var (
+		partPath   = filepath + PartSuffix
+		restarted  = false
+		restarting = false
+		err        error
+	)
+	for attempt := 0; attempt <= h.opts.Retries; attempt++ {
+		if attempt > 0 && !restarting {
+			delay := h.getBackoff(attempt)
+			logrus.WithFields(logrus.Fields{
+				logging.FieldFile: reader.GetFilename(filepath),
+				"attempt":         attempt,
+				"retries":         h.opts.Retries,
+				"delay":           delay.String(),
+			}).WithError(err).Warn("retrying download...")
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return fmt.Errorf("failed to download %w", ctx.Err())
+			}
+		}
+		restarting = false
+		if err = h.execGrabReq(h.newRequestWithChecksum(uri, partPath, checksum).WithContext(ctx)); err == nil {
+			return os.Rename(partPath, filepath)
+		}
+		if !restarted && isRangeNotSatisfiable(err) {
+			// part file is stale or already complete, which ranged request cannot resume
+			logrus.WithField(logging.FieldFile, reader.GetFilename(filepath)).WithError(err).
+				Warn("restarting download without part file...")
+			if err = os.Remove(partPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				break
+			}
+			// restart does not take an attempt nor wait for backoff
+			restarted, restarting, attempt = true, true, attempt-1
+			continue
+		}
+		if isPermanent(err) {
+			break
+		}
+	}
+	return fmt.Errorf("failed to download %w", err)
+}
+
+// isPermanent tells whether a download failed with err fails again on retry: checksum mismatch, cancellation, or
+// client error responses such as 403 and 404.
+func isPermanent(err error) bool {
+	var status grab.StatusCodeError
+	if errors.As(err, &status) {
+		return status >= 400 && status < 500 && status != http.StatusRequestedRangeNotSatisfiable
+	}
+	return errors.Is(err, grab.ErrBadChecksum) || errors.Is(err, context.Canceled)
+}
+
+// isRangeNotSatisfiable tells whether a download failed with err as its part file cannot be resumed.
+func isRangeNotSatisfiable(err error) bool {
+	var status grab.StatusCodeError
+	return errors.As(err, &status) && status == http.StatusRequestedRangeNotSatisfiable
+}
+
+func (h *handlerImpl) getBackoff(attempt int) time.Duration {
+	delay := h.opts.RetryBackoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
 }
 
 func (h *handlerImpl) getDecodedChecksum(checksum string) ([]byte, error) {
@@ -190,12 +294,15 @@ func (h *handlerImpl) Write(filepath string, content []byte, perm os.FileMode) e
 
 func (h *handlerImpl) newRequest(uri, filepath string) *grab.Request {
 	req, _ := grab.NewRequest(filepath, uri)
+	req.RateLimiter = h.limiter
 	return req
 }
 
 func (h *handlerImpl) newRequestWithChecksum(uri, filepath string, checksum []byte) *grab.Request {
 	req := h.newRequest(uri, filepath)
-	req.SetChecksum(sha256.New(), checksum, true)
+	if checksum != nil {
+		req.SetChecksum(sha256.New(), checksum, true)
+	}
 	return req
 }
 
@@ -203,32 +310,96 @@ func (h *handlerImpl) execGrabReq(req *grab.Request) error {
 	client := grab.NewClient()
 
 	// fire
+	h.downloads.Add(1)
+	defer h.downloads.Add(-1)
 	begin := time.Now()
 	resp := client.Do(req)
 
 	// monitor
-	pb := getProgressBar(reader.GetFilename(req.Filename), resp.Size())
+	var (
+		filename   = strings.TrimSuffix(reader.GetFilename(req.Filename), PartSuffix)
+		downloaded = metrics.DownloadedBytes.WithLabelValues(filename)
+		rate       = metrics.DownloadBytesPerSecond.WithLabelValues(filename)
+		reported   = resp.BytesComplete() // resumed bytes are not downloaded by this request
+	)
+	report := func() {
+		n := resp.BytesComplete()
+		downloaded.Add(float64(n - reported))
+		reported = n
+	}
+	p := h.newProgress(filename, resp.Size())
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 Loop:
 	for {
 		select {
 		case <-ticker.C:
-			_ = pb.Set64(resp.BytesComplete())
+			p.Set(resp.BytesComplete())
+			report()
+			rate.Set(resp.BytesPerSecond())
 		case <-resp.Done:
-			_ = pb.Finish()
+			p.Set(resp.BytesComplete())
+			p.Finish()
+			report()
+			rate.Set(0)
 			break Loop
 		}
 	}
-	fmt.Println()
 	if err := resp.Err(); err != nil {
-		defer func() { _ = os.Remove(req.Filename) }()
-		return fmt.Errorf("failed to download %w", err)
+		return err
 	}
-	fmt.Printf("download completed for %+v (took: %.2fs)\n", req.Filename, time.Since(begin).Seconds())
+	logrus.WithFields(logrus.Fields{
+		logging.FieldFile:    filename,
+		logging.FieldBytes:   resp.BytesComplete(),
+		logging.FieldElapsed: logging.Elapsed(begin),
+	}).Info("download completed")
 	return nil
 }
 
+// progress reports bytes downloaded, either by a progress bar or by progress events.
+type progress interface {
+	Set(n int64)
+	Finish()
+}
+
+// newProgress returns progress of a download. Progress bar falls back to progress events once other downloads of
+// the handler run concurrently, as their bars would interleave on the terminal.
+func (h *handlerImpl) newProgress(filename string, size int64) progress {
+	events := logging.NewProgress(logrus.Fields{logging.FieldStep: "download", logging.FieldFile: filename}, size)
+	alone := func() bool { return h.downloads.Load() <= 1 }
+	if !logging.ProgressBars() || !alone() {
+		return events
+	}
+	return &barProgress{pb: getProgressBar(filename, size), events: events, alone: alone}
+}
+
+type barProgress struct {
+	pb     *progressbar.ProgressBar
+	events *logging.Progress
+	alone  func() bool
+}
+
+func (b *barProgress) Set(n int64) {
+	if b.pb != nil && !b.alone() {
+		_ = b.pb.Clear()
+		b.pb = nil
+	}
+	if b.pb == nil {
+		b.events.Set(n)
+		return
+	}
+	_ = b.pb.Set64(n)
+}
+
+func (b *barProgress) Finish() {
+	if b.pb == nil {
+		b.events.Finish()
+		return
+	}
+	_ = b.pb.Finish()
+	fmt.Println()
+}
+
 func getProgressBar(filename string, size int64) *progressbar.ProgressBar {
 	pb := progressbar.NewOptions64(size,
 		progressbar.OptionEnableColorCodes(true),
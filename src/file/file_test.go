@@ -2,9 +2,12 @@ package file
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"github.com/sirupsen/logrus"
 	"github.com/state303/go-discogs/internal/test/resource"
 	"github.com/state303/go-discogs/internal/testserver"
+	"github.com/state303/go-discogs/src/logging"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"os"
@@ -223,8 +226,8 @@ func Test_handlerImpl_Fetch(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			h := &handlerImpl{}
 			var err error
-			defer func() { _ = os.Remove(tt.args.filepath) }()
-			if err = h.Fetch(tt.args.uri, tt.args.filepath); (err != nil) != tt.wantErr {
+			defer func() { _ = os.Remove(tt.args.filepath); _ = os.Remove(tt.args.filepath + PartSuffix) }()
+			if err = h.Fetch(context.Background(), tt.args.uri, tt.args.filepath); (err != nil) != tt.wantErr {
 				t.Errorf("Fetch() error = %v, wantErr %v", err, tt.wantErr)
 			}
 			if err == nil { // on success
@@ -316,7 +319,8 @@ func Test_handlerImpl_FetchAndCheck(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			h := NewHandler()
-			if err := h.FetchAndCheck(tt.args.uri, tt.args.filepath, tt.args.checksum); (err != nil) != tt.wantErr {
+			defer func() { _ = os.Remove(tt.args.filepath + PartSuffix) }()
+			if err := h.FetchAndCheck(context.Background(), tt.args.uri, tt.args.filepath, tt.args.checksum); (err != nil) != tt.wantErr {
 				t.Errorf("FetchAndCheck() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -441,7 +445,7 @@ func TestNewHandler(t *testing.T) {
 	}{
 		{
 			name: "creates handler",
-			want: &handlerImpl{&fileReaderImpl{}},
+			want: &handlerImpl{reader: &fileReaderImpl{}},
 		},
 	}
 	for _, tt := range tests {
@@ -450,3 +454,146 @@ func TestNewHandler(t *testing.T) {
 		})
 	}
 }
+
+func Test_handlerImpl_FetchRetries(t *testing.T) {
+	expected := resource.Read("testdata/test.xml")
+	flakyServer := testserver.NewServer(func(requests []*testserver.HttpRequest, w http.ResponseWriter, r *http.Request) {
+		if len(requests) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(expected)
+	})
+	defer flakyServer.Close()
+
+	filepath := t.TempDir() + "/fetch_retry.xml"
+	t.Run("returns err when out of retries", func(t *testing.T) {
+		h := NewHandlerWithOptions(Options{Retries: 1, RetryBackoff: time.Millisecond})
+		assert.Error(t, h.Fetch(context.Background(), flakyServer.URL, filepath))
+	})
+	t.Run("succeeds within retries", func(t *testing.T) {
+		h := NewHandlerWithOptions(Options{Retries: 1, RetryBackoff: time.Millisecond})
+		assert.NoError(t, h.Fetch(context.Background(), flakyServer.URL, filepath))
+		compareFilesByBytes(t, filepath, "testdata/test.xml")
+		_, err := os.Stat(filepath + PartSuffix)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func Test_handlerImpl_FetchResumesPartFile(t *testing.T) {
+	expected := resource.Read("testdata/test.xml")
+	s := testserver.NewServer(func(requests []*testserver.HttpRequest, w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "test.xml", time.Time{}, bytes.NewReader(expected))
+	})
+	defer s.Close()
+
+	filepath := t.TempDir() + "/fetch_resume.xml"
+	assert.NoError(t, os.WriteFile(filepath+PartSuffix, expected[:100], 0644))
+
+	h := NewHandler()
+	assert.NoError(t, h.FetchAndCheck(context.Background(), s.URL, filepath, "69718470e15145cf586db15389bb2bf81b4cf4ee179aa6c0dd61afaf17d56b3d"))
+	compareFilesByBytes(t, filepath, "testdata/test.xml")
+
+	ranged := false
+	for _, r := range s.Requests() {
+		if r.Header.Get("Range") == "bytes=100-" {
+			ranged = true
+		}
+	}
+	assert.True(t, ranged)
+}
+
+func Test_handlerImpl_FetchRestartsOnRangeNotSatisfiable(t *testing.T) {
+	expected := resource.Read("testdata/test.xml")
+	s := testserver.NewServer(func(requests []*testserver.HttpRequest, w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		http.ServeContent(w, r, "test.xml", time.Time{}, bytes.NewReader(expected))
+	})
+	defer s.Close()
+
+	filepath := t.TempDir() + "/fetch_stale.xml"
+	assert.NoError(t, os.WriteFile(filepath+PartSuffix, []byte("stale"), 0644))
+
+	h := NewHandlerWithOptions(Options{Retries: 0, RetryBackoff: time.Hour})
+	assert.NoError(t, h.FetchAndCheck(context.Background(), s.URL, filepath, "69718470e15145cf586db15389bb2bf81b4cf4ee179aa6c0dd61afaf17d56b3d"))
+	compareFilesByBytes(t, filepath, "testdata/test.xml")
+	ranged := 0
+	for _, r := range s.Requests() {
+		if r.Header.Get("Range") != "" {
+			ranged++
+		}
+	}
+	assert.Equal(t, 1, ranged)
+}
+
+func Test_barProgress_FallsBackToEventsWhenConcurrent(t *testing.T) {
+	alone := true
+	b := &barProgress{
+		pb:     getProgressBar("concurrent.xml", 100),
+		events: logging.NewProgress(logrus.Fields{}, 100),
+		alone:  func() bool { return alone },
+	}
+	b.Set(10)
+	assert.NotNil(t, b.pb)
+
+	alone = false
+	b.Set(20)
+	assert.Nil(t, b.pb)
+
+	alone = true
+	b.Set(30)
+	assert.Nil(t, b.pb, "bar must not be redrawn once cleared")
+	b.Finish()
+}
+
+func Test_handlerImpl_getBackoff(t *testing.T) {
+	h := &handlerImpl{opts: Options{RetryBackoff: time.Second}}
+	assert.Equal(t, time.Second, h.getBackoff(1))
+	assert.Equal(t, 2*time.Second, h.getBackoff(2))
+	assert.Equal(t, 4*time.Second, h.getBackoff(3))
+	assert.Equal(t, maxBackoff, h.getBackoff(10))
+}
+
+func Test_handlerImpl_FetchStopsOnCancel(t *testing.T) {
+	s := testserver.NewServer(func(requests []*testserver.HttpRequest, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1000")
+		_, _ = w.Write([]byte("<"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // never completes until the client leaves
+	})
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	h := NewHandlerWithOptions(Options{Retries: 3, RetryBackoff: time.Millisecond})
+	err := h.Fetch(ctx, s.URL, t.TempDir()+"/fetch_cancel.xml")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_handlerImpl_FetchDoesNotRetryClientError(t *testing.T) {
+	s := testserver.NewServer(func(requests []*testserver.HttpRequest, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer s.Close()
+
+	h := NewHandlerWithOptions(Options{Retries: 3, RetryBackoff: time.Hour})
+	assert.Error(t, h.Fetch(context.Background(), s.URL, t.TempDir()+"/fetch_not_found.xml"))
+	assert.Len(t, s.Requests(), 1)
+}
+
+func Test_handlerImpl_FetchBackoffStopsOnCancel(t *testing.T) {
+	s := testserver.NewServer(func(requests []*testserver.HttpRequest, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	h := NewHandlerWithOptions(Options{Retries: 3, RetryBackoff: time.Hour})
+	err := h.Fetch(ctx, s.URL, t.TempDir()+"/fetch_backoff.xml")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, s.Requests(), 1)
+}
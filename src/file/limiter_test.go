@@ -0,0 +1,26 @@
+package file
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("spaces reads by given rate", func(t *testing.T) {
+		l := newRateLimiter(1000)
+		begin := time.Now()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, l.WaitN(context.Background(), 100))
+		}
+		require.GreaterOrEqual(t, time.Since(begin), 200*time.Millisecond)
+	})
+	t.Run("returns err when context is done", func(t *testing.T) {
+		l := newRateLimiter(1)
+		require.NoError(t, l.WaitN(context.Background(), 100))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, l.WaitN(ctx, 1), context.Canceled)
+	})
+}
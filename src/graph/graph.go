@@ -0,0 +1,169 @@
+package graph
+
+import (
+	"encoding/csv"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Node is a neo4j node label. Each node is written into its own csv file.
+type Node string
+
+const (
+	Artist  Node = "Artist"
+	Label   Node = "Label"
+	Master  Node = "Master"
+	Release Node = "Release"
+	Genre   Node = "Genre"
+	Style   Node = "Style"
+)
+
+// Relation describes a neo4j relationship type between two nodes, with its optional properties.
+type Relation struct {
+	Type  string
+	From  Node
+	To    Node
+	Props []string
+}
+
+var (
+	AliasOf       = Relation{Type: "ALIAS_OF", From: Artist, To: Artist}
+	MemberOf      = Relation{Type: "MEMBER_OF", From: Artist, To: Artist}
+	SublabelOf    = Relation{Type: "SUBLABEL_OF", From: Label, To: Label}
+	MasterArtist  = Relation{Type: "ARTIST_OF", From: Artist, To: Master}
+	ReleaseArtist = Relation{Type: "ARTIST_OF", From: Artist, To: Release}
+	CreditedOn    = Relation{Type: "CREDITED_ON", From: Artist, To: Release, Props: []string{"role"}}
+	ReleasedOn    = Relation{Type: "RELEASED_ON", From: Release, To: Label, Props: []string{"catno"}}
+	VersionOf     = Relation{Type: "VERSION_OF", From: Release, To: Master}
+	MasterGenre   = Relation{Type: "HAS_GENRE", From: Master, To: Genre}
+	MasterStyle   = Relation{Type: "HAS_STYLE", From: Master, To: Style}
+	ReleaseGenre  = Relation{Type: "HAS_GENRE", From: Release, To: Genre}
+	ReleaseStyle  = Relation{Type: "HAS_STYLE", From: Release, To: Style}
+)
+
+// nodeProps holds property columns of each node, written between the id and the label column.
+var nodeProps = map[Node][]string{
+	Artist:  {"name", "realName", "profile", "dataQuality"},
+	Label:   {"name", "contactInfo", "profile", "dataQuality"},
+	Master:  {"title", "year:int", "dataQuality"},
+	Release: {"title", "country", "released", "year:int", "status", "dataQuality"},
+	Genre:   {},
+	Style:   {},
+}
+
+// NodeHeader returns the neo4j-admin import header of given node.
+func NodeHeader(n Node) []string {
+	idColumn := strings.ToLower(string(n)) + "Id:ID(" + string(n) + ")"
+	if n == Genre || n == Style {
+		idColumn = "name:ID(" + string(n) + ")"
+	}
+	header := append([]string{idColumn}, nodeProps[n]...)
+	return append(header, ":LABEL")
+}
+
+// RelationHeader returns the neo4j-admin import header of given relation.
+func RelationHeader(r Relation) []string {
+	header := []string{":START_ID(" + string(r.From) + ")", ":END_ID(" + string(r.To) + ")", ":TYPE"}
+	return append(header, r.Props...)
+}
+
+// NodeFilename returns csv filename of given node, such as artist.csv
+func NodeFilename(n Node) string {
+	return strings.ToLower(string(n)) + ".csv"
+}
+
+// RelationFilename returns csv filename of given relation, such as artist_alias_of_artist.csv
+func RelationFilename(r Relation) string {
+	return strings.ToLower(string(r.From) + "_" + r.Type + "_" + string(r.To) + ".csv")
+}
+
+// Writer writes nodes and relationships for neo4j-admin bulk import.
+type Writer interface {
+	// WriteNode writes a node with id and its properties, in order of the node header.
+	// Genre and Style nodes are identified by name, hence written only once.
+	WriteNode(n Node, id string, props ...string) error
+	// WriteRelation writes a relationship from start id to end id, with properties in order of Relation.Props.
+	WriteRelation(r Relation, start, end string, props ...string) error
+	// Close flushes and closes all files written so far.
+	Close() error
+}
+
+// NewCSVWriter returns Writer that writes csv files into given directory.
+// Files are created on their first record, along with their header.
+func NewCSVWriter(dir string) (Writer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &csvWriter{
+		dir:     dir,
+		files:   make(map[string]*os.File),
+		writers: make(map[string]*csv.Writer),
+		named:   map[Node]map[string]struct{}{Genre: {}, Style: {}},
+	}, nil
+}
+
+type csvWriter struct {
+	mu      sync.Mutex
+	dir     string
+	files   map[string]*os.File
+	writers map[string]*csv.Writer
+	named   map[Node]map[string]struct{}
+}
+
+func (c *csvWriter) WriteNode(n Node, id string, props ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if seen, ok := c.named[n]; ok {
+		if _, found := seen[id]; found {
+			return nil
+		}
+		seen[id] = struct{}{}
+	}
+	record := append(append([]string{id}, props...), string(n))
+	return c.write(NodeFilename(n), NodeHeader(n), record)
+}
+
+func (c *csvWriter) WriteRelation(r Relation, start, end string, props ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	record := append([]string{start, end, r.Type}, props...)
+	return c.write(RelationFilename(r), RelationHeader(r), record)
+}
+
+func (c *csvWriter) write(filename string, header []string, record []string) error {
+	w, ok := c.writers[filename]
+	if !ok {
+		f, err := os.Create(path.Join(c.dir, filename))
+		if err != nil {
+			return err
+		}
+		w = csv.NewWriter(f)
+		if err = w.Write(header); err != nil {
+			_ = f.Close()
+			return err
+		}
+		c.files[filename] = f
+		c.writers[filename] = w
+	}
+	return w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var err error
+	for filename, w := range c.writers {
+		w.Flush()
+		if flushErr := w.Error(); flushErr != nil && err == nil {
+			err = flushErr
+		}
+		if closeErr := c.files[filename].Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	c.writers = make(map[string]*csv.Writer)
+	c.files = make(map[string]*os.File)
+	return err
+}
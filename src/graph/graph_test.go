@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"encoding/csv"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path"
+	"testing"
+)
+
+func readCsv(t *testing.T, filepath string) [][]string {
+	f, err := os.Open(filepath)
+	require.NoError(t, err)
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestNodeHeader(t *testing.T) {
+	t.Run("id column is typed by node", func(t *testing.T) {
+		require.Equal(t, []string{"artistId:ID(Artist)", "name", "realName", "profile", "dataQuality", ":LABEL"}, NodeHeader(Artist))
+	})
+	t.Run("genre and style are identified by name", func(t *testing.T) {
+		require.Equal(t, []string{"name:ID(Genre)", ":LABEL"}, NodeHeader(Genre))
+		require.Equal(t, []string{"name:ID(Style)", ":LABEL"}, NodeHeader(Style))
+	})
+}
+
+func TestRelationHeader(t *testing.T) {
+	require.Equal(t, []string{":START_ID(Artist)", ":END_ID(Release)", ":TYPE", "role"}, RelationHeader(CreditedOn))
+	require.Equal(t, "artist_credited_on_release.csv", RelationFilename(CreditedOn))
+}
+
+func TestCSVWriter(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewCSVWriter(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteNode(Artist, "1", "The Persuader", "Jesper Dahlbäck", "", "Needs Vote"))
+	require.NoError(t, w.WriteNode(Genre, "Electronic"))
+	require.NoError(t, w.WriteNode(Genre, "Electronic"))
+	require.NoError(t, w.WriteRelation(ReleasedOn, "10", "20", "CAT 001"))
+	require.NoError(t, w.Close())
+
+	t.Run("node file starts with header", func(t *testing.T) {
+		records := readCsv(t, path.Join(dir, "artist.csv"))
+		require.Len(t, records, 2)
+		require.Equal(t, NodeHeader(Artist), records[0])
+		require.Equal(t, []string{"1", "The Persuader", "Jesper Dahlbäck", "", "Needs Vote", "Artist"}, records[1])
+	})
+	t.Run("named node is written once", func(t *testing.T) {
+		records := readCsv(t, path.Join(dir, "genre.csv"))
+		require.Len(t, records, 2)
+	})
+	t.Run("relation carries types and props", func(t *testing.T) {
+		records := readCsv(t, path.Join(dir, RelationFilename(ReleasedOn)))
+		require.Equal(t, []string{"10", "20", "RELEASED_ON", "CAT 001"}, records[1])
+	})
+	t.Run("unused files are not created", func(t *testing.T) {
+		_, err := os.Stat(path.Join(dir, "label.csv"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
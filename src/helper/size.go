@@ -0,0 +1,53 @@
+package helper
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var byteSizePattern = regexp.MustCompile(`^(\d+)\s*([kmgt]?)i?b?$`)
+
+// ParseByteSize parses human-readable size such as 512K, 10MB or 1GiB into bytes, in multiples of 1024.
+// Empty string is zero.
+func ParseByteSize(s string) (int64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) == 0 {
+		return 0, nil
+	}
+	match := byteSizePattern.FindStringSubmatch(s)
+	if match == nil {
+		return 0, fmt.Errorf("invalid size: %+v", s)
+	}
+	n, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size: %+v", s)
+	}
+	for _, unit := range "kmgt" {
+		if len(match[2]) == 0 {
+			break
+		}
+		n *= 1024
+		if match[2] == string(unit) {
+			break
+		}
+	}
+	return n, nil
+}
+
+// FormatByteSize formats bytes into human-readable size in multiples of 1024, such as 1.5G.
+func FormatByteSize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	size, unit := float64(n), ""
+	for _, u := range "KMGT" {
+		if size < 1024 {
+			break
+		}
+		size /= 1024
+		unit = string(u)
+	}
+	return fmt.Sprintf("%.1f%s", size, unit)
+}
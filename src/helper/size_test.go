@@ -0,0 +1,43 @@
+package helper
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	t.Run("must parse sizes with units", func(t *testing.T) {
+		for s, expected := range map[string]int64{
+			"":      0,
+			"512":   512,
+			"512b":  512,
+			"1K":    1024,
+			"10MB":  10 * 1024 * 1024,
+			"2 MiB": 2 * 1024 * 1024,
+			"1g":    1024 * 1024 * 1024,
+		} {
+			n, err := ParseByteSize(s)
+			require.NoError(t, err)
+			require.Equal(t, expected, n, s)
+		}
+	})
+	t.Run("must return err on invalid size", func(t *testing.T) {
+		for _, s := range []string{"-1", "ten", "10X", "1.5M"} {
+			_, err := ParseByteSize(s)
+			require.ErrorContains(t, err, "invalid size")
+		}
+	})
+}
+
+func TestFormatByteSize(t *testing.T) {
+	for n, expected := range map[int64]string{
+		0:                      "0B",
+		1023:                   "1023B",
+		1024:                   "1.0K",
+		1536:                   "1.5K",
+		10 * 1024 * 1024:       "10.0M",
+		3 * 1024 * 1024 * 1024: "3.0G",
+	} {
+		require.Equal(t, expected, FormatByteSize(n))
+	}
+}
@@ -0,0 +1,132 @@
+package history
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/state303/go-discogs/src/database"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const (
+	StatusRunning     = "running"
+	StatusSucceeded   = "succeeded"
+	StatusFailed      = "failed"
+	StatusInterrupted = "interrupted"
+)
+
+// Run is a record of a batch run, along with dumps it loaded and the outcome.
+type Run struct {
+	ID         int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	StartedAt  time.Time  `gorm:"column:started_at;not null" json:"started_at"`
+	EndedAt    *time.Time `gorm:"column:ended_at" json:"ended_at,omitempty"`
+	Version    string     `gorm:"column:version" json:"version"`
+	Generation string     `gorm:"column:generation" json:"generation"`
+	// Types are target types of the run, separated by comma.
+	Types string `gorm:"column:types" json:"types"`
+	// ETags are etags of dump data used by the run, separated by comma.
+	ETags string `gorm:"column:etags" json:"etags"`
+	// Completed are types loaded by the run, separated by comma. Types completed by an interrupted run are skipped by
+	// the next run of the same generation and types.
+	Completed string `gorm:"column:completed" json:"completed"`
+	Counts    Counts `gorm:"column:counts" json:"counts"`
+	Updated   int    `gorm:"column:updated" json:"updated"`
+	Status    string `gorm:"column:status" json:"status"`
+	Error     string `gorm:"column:error" json:"error,omitempty"`
+}
+
+func (Run) TableName() string {
+	return "batch_run"
+}
+
+// Counts maps each type to number of records updated by its step, stored as json.
+type Counts map[string]int
+
+func (c Counts) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+func (c *Counts) Scan(src interface{}) error {
+	*c = nil
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return fmt.Errorf("unsupported counts of type %T", src)
+}
+
+// GetStatus returns status of a run ended with err.
+func GetStatus(err error) string {
+	switch {
+	case err == nil:
+		return StatusSucceeded
+	case errors.Is(err, context.Canceled):
+		return StatusInterrupted
+	}
+	return StatusFailed
+}
+
+// Resumed returns types completed by prev, if run resumes it. A run resumes prev when prev was interrupted while
+// loading the same generation and types.
+func Resumed(prev, run *Run) []string {
+	if prev == nil || prev.Status != StatusInterrupted || len(prev.Completed) == 0 ||
+		prev.Generation != run.Generation || prev.Types != run.Types {
+		return nil
+	}
+	return strings.Split(prev.Completed, ",")
+}
+
+type Repository interface {
+	// Start inserts the run, assigning its ID.
+	Start(run *Run) error
+	// Finish updates the run with its outcome.
+	Finish(run *Run) error
+	// FindLast returns up to n runs, newest first.
+	FindLast(n int) ([]*Run, error)
+}
+
+// Migrate creates batch_run table if missing, or adds columns missing from the one created by former versions.
+func Migrate(db *gorm.DB) error {
+	m := db.Table(database.TableName(Run{}.TableName())).Migrator()
+	if !m.HasTable(&Run{}) {
+		return m.CreateTable(&Run{})
+	}
+	if m.HasColumn(&Run{}, "Completed") {
+		return nil
+	}
+	return m.AddColumn(&Run{}, "Completed")
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repositoryImpl{db: db}
+}
+
+type repositoryImpl struct {
+	db *gorm.DB
+}
+
+func (r *repositoryImpl) Start(run *Run) error {
+	return r.db.Session(&gorm.Session{}).Create(run).Error
+}
+
+func (r *repositoryImpl) Finish(run *Run) error {
+	return r.db.Session(&gorm.Session{}).Save(run).Error
+}
+
+func (r *repositoryImpl) FindLast(n int) ([]*Run, error) {
+	runs := make([]*Run, 0)
+	err := r.db.Session(&gorm.Session{}).Order("started_at DESC").Order("id DESC").Limit(n).Find(&runs).Error
+	return runs, err
+}
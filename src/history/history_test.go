@@ -0,0 +1,44 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCounts(t *testing.T) {
+	v, err := Counts{"artists": 3}.Value()
+	require.NoError(t, err)
+	require.Equal(t, `{"artists":3}`, v)
+
+	v, err = Counts(nil).Value()
+	require.NoError(t, err)
+	require.Equal(t, "{}", v)
+
+	var c Counts
+	require.NoError(t, c.Scan([]byte(`{"labels":2}`)))
+	require.Equal(t, Counts{"labels": 2}, c)
+	require.NoError(t, c.Scan(`{"masters":1}`))
+	require.Equal(t, Counts{"masters": 1}, c)
+	require.Error(t, c.Scan(1))
+}
+
+func TestGetStatus(t *testing.T) {
+	require.Equal(t, StatusSucceeded, GetStatus(nil))
+	require.Equal(t, StatusInterrupted, GetStatus(fmt.Errorf("step: %w", context.Canceled)))
+	require.Equal(t, StatusFailed, GetStatus(errors.New("failed")))
+}
+
+func TestResumed(t *testing.T) {
+	run := &Run{Generation: "2024-01", Types: "artists,labels"}
+	prev := &Run{Generation: "2024-01", Types: "artists,labels", Status: StatusInterrupted, Completed: "artists"}
+	require.Equal(t, []string{"artists"}, Resumed(prev, run))
+
+	require.Empty(t, Resumed(nil, run))
+	require.Empty(t, Resumed(&Run{Generation: "2024-01", Types: "artists,labels", Status: StatusFailed, Completed: "artists"}, run))
+	require.Empty(t, Resumed(&Run{Generation: "2023-12", Types: "artists,labels", Status: StatusInterrupted, Completed: "artists"}, run))
+	require.Empty(t, Resumed(&Run{Generation: "2024-01", Types: "artists", Status: StatusInterrupted, Completed: "artists"}, run))
+	require.Empty(t, Resumed(&Run{Generation: "2024-01", Types: "artists,labels", Status: StatusInterrupted}, run))
+}
@@ -0,0 +1,131 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Field names shared by log entries.
+const (
+	FieldStep    = "step"
+	FieldType    = "type"
+	FieldChunk   = "chunk"
+	FieldCount   = "count"
+	FieldElapsed = "elapsed"
+	FieldBytes   = "bytes"
+	FieldTotal   = "total"
+	FieldFile    = "file"
+)
+
+// ProgressInterval is the interval of progress events logged in place of progress bars.
+var ProgressInterval = 10 * time.Second
+
+// progressBars tells whether progress bars are drawn. Disabled by Configure unless text is written to a terminal.
+var progressBars = true
+
+// Configure routes every log entry of logrus into w in given format, either text or json.
+// Progress bars are drawn only for text format written to a terminal, otherwise replaced by progress events.
+func Configure(format string, w io.Writer) error {
+	switch format {
+	case FormatText, "":
+		logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	case FormatJSON:
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format: %+v", format)
+	}
+	logrus.SetOutput(w)
+	progressBars = format != FormatJSON && isTerminal(w)
+	return nil
+}
+
+// ProgressBars tells whether progress bars are drawn, rather than progress events logged.
+func ProgressBars() bool {
+	return progressBars
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// Elapsed returns seconds since begin, rounded to milliseconds.
+func Elapsed(begin time.Time) float64 {
+	return time.Since(begin).Round(time.Millisecond).Seconds()
+}
+
+// Progress logs progress events of a long-running task at ProgressInterval. It is safe for concurrent use.
+type Progress struct {
+	mu     sync.Mutex
+	entry  *logrus.Entry
+	total  int64
+	n      int64
+	begin  time.Time
+	logged time.Time
+}
+
+// NewProgress returns Progress of a task described by fields. Total is omitted from events if negative.
+func NewProgress(fields logrus.Fields, total int64) *Progress {
+	now := time.Now()
+	return &Progress{entry: logrus.WithFields(fields), total: total, begin: now, logged: now}
+}
+
+// Add adds n bytes to the progress, logging an event once ProgressInterval passed since the last.
+func (p *Progress) Add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.n += n
+	if time.Since(p.logged) >= ProgressInterval {
+		p.log("progress")
+	}
+}
+
+// Set sets bytes of the progress, logging an event once ProgressInterval passed since the last.
+func (p *Progress) Set(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.n = n
+	if time.Since(p.logged) >= ProgressInterval {
+		p.log("progress")
+	}
+}
+
+// Finish logs the final event.
+func (p *Progress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.log("progress finished")
+}
+
+func (p *Progress) log(msg string) {
+	p.logged = time.Now()
+	fields := logrus.Fields{FieldBytes: p.n, FieldElapsed: Elapsed(p.begin)}
+	if p.total >= 0 {
+		fields[FieldTotal] = p.total
+	}
+	p.entry.WithFields(fields).Info(msg)
+}
+
+// Writer returns io.Writer adding length of each write to the progress, such as for io.TeeReader.
+func (p *Progress) Writer() io.Writer {
+	return progressWriter{p}
+}
+
+type progressWriter struct {
+	p *Progress
+}
+
+func (w progressWriter) Write(b []byte) (int, error) {
+	w.p.Add(int64(len(b)))
+	return len(b), nil
+}
@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigure(t *testing.T) {
+	defer func() { _ = Configure(FormatText, os.Stdout) }()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Configure(FormatJSON, buf))
+	require.False(t, ProgressBars())
+	logrus.WithField(FieldCount, 3).Info("updated")
+	entry := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "updated", entry["msg"])
+	require.Equal(t, float64(3), entry[FieldCount])
+
+	buf.Reset()
+	require.NoError(t, Configure(FormatText, buf))
+	require.False(t, ProgressBars(), "not a terminal")
+	logrus.WithField(FieldCount, 3).Info("updated")
+	require.Contains(t, buf.String(), "msg=updated count=3")
+
+	require.ErrorContains(t, Configure("xml", buf), "unknown log format")
+}
+
+func TestProgress(t *testing.T) {
+	defer func(interval time.Duration) {
+		ProgressInterval = interval
+		_ = Configure(FormatText, os.Stdout)
+	}(ProgressInterval)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Configure(FormatJSON, buf))
+
+	ProgressInterval = time.Hour
+	p := NewProgress(logrus.Fields{FieldFile: "a.xml.gz"}, -1)
+	_, _ = p.Writer().Write(make([]byte, 10))
+	require.Zero(t, buf.Len(), "nothing logged within interval")
+
+	ProgressInterval = 0
+	p.Add(5)
+	p.Set(20)
+	p.Finish()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var last map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &last))
+	require.Equal(t, "progress finished", last["msg"])
+	require.Equal(t, "a.xml.gz", last[FieldFile])
+	require.Equal(t, float64(20), last[FieldBytes])
+	require.NotContains(t, last, FieldTotal)
+
+	buf.Reset()
+	NewProgress(logrus.Fields{}, 100).Finish()
+	require.Contains(t, buf.String(), `"total":100`)
+}
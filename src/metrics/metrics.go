@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/state303/go-discogs/src/cache"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const namespace = "discogs"
+
+// Registry holds every metric of the batch, along with go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// RecordsParsed counts elements decoded from dump files, by element name such as artist.
+	RecordsParsed = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "records_parsed_total",
+		Help:      "Elements decoded from dump files.",
+	}, []string{"type"})
+
+	// RecordsWritten counts rows affected by writes, by table.
+	RecordsWritten = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "records_written_total",
+		Help:      "Rows affected by writes into the database.",
+	}, []string{"table"})
+
+	// ChunkWriteSeconds observes latency of writing a chunk of rows, by table.
+	ChunkWriteSeconds = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "chunk_write_seconds",
+		Help:      "Latency of writing a chunk of rows into the database.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"table"})
+
+	// BytesDecompressed counts bytes read from decompressed dump files, by file name.
+	BytesDecompressed = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bytes_decompressed_total",
+		Help:      "Bytes read from decompressed dump files.",
+	}, []string{"file"})
+
+	// RejectedRecords counts elements and rows skipped or dead-lettered by the error policy, by topic and policy.
+	RejectedRecords = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rejected_records_total",
+		Help:      "Elements and rows skipped or dead-lettered by the error policy.",
+	}, []string{"topic", "policy"})
+
+	// DownloadedBytes counts bytes downloaded, by file name.
+	DownloadedBytes = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "downloaded_bytes_total",
+		Help:      "Bytes of dump files downloaded.",
+	}, []string{"file"})
+
+	// DownloadBytesPerSecond is the current transfer rate of each download in progress, by file name.
+	DownloadBytesPerSecond = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "download_bytes_per_second",
+		Help:      "Transfer rate of downloads, zero once finished.",
+	}, []string{"file"})
+)
+
+func init() {
+	Registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	caches := map[string]*sync.Map{
+		"style":  cache.StyleCache,
+		"genre":  cache.GenreCache,
+		"artist": cache.ArtistIDCache,
+		"label":  cache.LabelIDCache,
+		"master": cache.MasterIDCache,
+	}
+	for name, m := range caches {
+		m := m
+		promauto.With(Registry).NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "cache_entries",
+			Help:        "Entries of in-memory caches. Counted on each scrape.",
+			ConstLabels: prometheus.Labels{"cache": name},
+		}, func() float64 {
+			return float64(countEntries(m))
+		})
+	}
+}
+
+func countEntries(m *sync.Map) (n int) {
+	m.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return
+}
+
+// Serve starts serving metrics at /metrics of addr, such as :9090, until the returned server is shut down.
+// Error is returned only if addr cannot be listened on.
+func Serve(addr string) (*http.Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			_ = l.Close()
+		}
+	}()
+	return srv, nil
+}
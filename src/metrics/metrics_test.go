@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestCountEntries(t *testing.T) {
+	m := &sync.Map{}
+	require.Zero(t, countEntries(m))
+	m.Store(1, struct{}{})
+	m.Store(2, struct{}{})
+	require.Equal(t, 2, countEntries(m))
+}
+
+func TestServe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	srv, err := Serve(addr)
+	require.NoError(t, err)
+	defer func() { _ = srv.Close() }()
+
+	RecordsWritten.WithLabelValues("artist").Add(3)
+	res, err := http.Get("http://" + addr + "/metrics")
+	require.NoError(t, err)
+	defer func() { _ = res.Body.Close() }()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `discogs_records_written_total{table="artist"} 3`)
+	require.Contains(t, string(body), `discogs_cache_entries{cache="artist"}`)
+
+	_, err = Serve(addr)
+	require.Error(t, err, "address in use")
+}
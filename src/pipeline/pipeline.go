@@ -0,0 +1,170 @@
+package pipeline
+
+import (
+	"context"
+	"github.com/state303/go-discogs/src/result"
+	"runtime"
+	"sync"
+)
+
+// DefaultWriters is the number of concurrent writes unless configured.
+const DefaultWriters = 4
+
+// Source sends items into out until exhausted, returning the error that stopped it, if any.
+// It must return once ctx is done. Out is closed by the pipeline once Source returns.
+type Source[T any] func(ctx context.Context, out chan<- T) error
+
+// Options configures concurrency of Run. Zero values are replaced by defaults.
+type Options struct {
+	// ChunkSize is the number of items per chunk. Defaults to 1.
+	ChunkSize int
+	// Workers is the number of goroutines preparing chunks. Defaults to runtime.NumCPU.
+	Workers int
+	// Writers is the number of chunks written concurrently. Defaults to DefaultWriters.
+	Writers int
+	// Queue is the number of prepared chunks waiting for a writer. Defaults to Writers.
+	// Preparing blocks while the queue is full, hence memory is bounded however slow writes are.
+	Queue int
+}
+
+func (o Options) withDefaults() Options {
+	if o.ChunkSize < 1 {
+		o.ChunkSize = 1
+	}
+	if o.Workers < 1 {
+		o.Workers = runtime.NumCPU()
+	}
+	if o.Writers < 1 {
+		o.Writers = DefaultWriters
+	}
+	if o.Queue < 1 {
+		o.Queue = o.Writers
+	}
+	return o
+}
+
+type chunk[T any] struct {
+	seq   int
+	value T
+}
+
+// Run reads items of src in chunks of ChunkSize, prepares each chunk on Workers goroutines, then writes prepared
+// chunks on Writers goroutines, returning count of every chunk written.
+// The first failure stops reading, while chunks prepared already are still written. The error returned is the one
+// of the earliest chunk failed, regardless of timing, followed by error of src.
+func Run[T, C any](ctx context.Context, src Source[T], prepare func(items []T) (C, error), write func(c C) result.Result, opts Options) result.Result {
+	opts = opts.withDefaults()
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		items    = make(chan T, opts.ChunkSize)
+		chunks   = make(chan chunk[[]T])
+		prepared = make(chan chunk[C], opts.Queue)
+		failure  = &firstError{cancel: cancel}
+		srcErr   error
+	)
+
+	go func() {
+		defer close(items)
+		srcErr = src(readCtx, items)
+	}()
+
+	go func() {
+		defer close(chunks)
+		seq := 0
+		emit := func(c []T) bool {
+			select {
+			case chunks <- chunk[[]T]{seq: seq, value: c}:
+				seq++
+				return true
+			case <-readCtx.Done():
+				return false
+			}
+		}
+		buf := make([]T, 0, opts.ChunkSize)
+		for item := range items {
+			buf = append(buf, item)
+			if len(buf) == opts.ChunkSize {
+				if !emit(buf) {
+					break
+				}
+				buf = make([]T, 0, opts.ChunkSize)
+			}
+		}
+		for range items { // drain, so that src returns
+		}
+		if len(buf) > 0 && readCtx.Err() == nil {
+			emit(buf)
+		}
+		if srcErr != nil {
+			failure.set(seq, srcErr)
+		}
+	}()
+
+	workers := new(sync.WaitGroup)
+	for i := 0; i < opts.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for c := range chunks {
+				v, err := prepare(c.value)
+				if err != nil {
+					failure.set(c.seq, err)
+					continue
+				}
+				prepared <- chunk[C]{seq: c.seq, value: v}
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(prepared)
+	}()
+
+	var (
+		mu      sync.Mutex
+		count   int
+		writers = new(sync.WaitGroup)
+	)
+	for i := 0; i < opts.Writers; i++ {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for c := range prepared {
+				r := write(c.value)
+				mu.Lock()
+				count += r.Count()
+				mu.Unlock()
+				if r.IsErr() {
+					failure.set(c.seq, r.Err())
+				}
+			}
+		}()
+	}
+	writers.Wait()
+	return result.NewResult(count, failure.get())
+}
+
+// firstError keeps error of the earliest chunk, cancelling reading on the first error.
+type firstError struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	seq    int
+	err    error
+}
+
+func (f *firstError) set(seq int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err == nil || seq < f.seq {
+		f.seq, f.err = seq, err
+	}
+	f.cancel()
+}
+
+func (f *firstError) get() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"github.com/state303/go-discogs/src/result"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rangeSource sends 0 to n-1, then returns err.
+func rangeSource(n int, err error) Source[int] {
+	return func(ctx context.Context, out chan<- int) error {
+		for i := 0; i < n; i++ {
+			select {
+			case out <- i:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		return err
+	}
+}
+
+func sum(items []int) (int, error) {
+	s := 0
+	for _, i := range items {
+		s += i
+	}
+	return s, nil
+}
+
+func TestRun(t *testing.T) {
+	t.Run("writes every chunk", func(t *testing.T) {
+		var total, chunks int64
+		res := Run(context.Background(), rangeSource(10, nil), func(items []int) ([]int, error) {
+			return items, nil
+		}, func(items []int) result.Result {
+			s, _ := sum(items)
+			atomic.AddInt64(&total, int64(s))
+			atomic.AddInt64(&chunks, 1)
+			return result.NewResult(len(items), nil)
+		}, Options{ChunkSize: 3, Workers: 2, Writers: 2})
+		require.NoError(t, res.Err())
+		assert.Equal(t, 10, res.Count())
+		assert.Equal(t, int64(45), total)
+		assert.Equal(t, int64(4), chunks)
+	})
+	t.Run("limits concurrent writes", func(t *testing.T) {
+		var running, peak int64
+		res := Run(context.Background(), rangeSource(40, nil), sum, func(int) result.Result {
+			n := atomic.AddInt64(&running, 1)
+			for {
+				p := atomic.LoadInt64(&peak)
+				if n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt64(&running, -1)
+			return result.NewResult(1, nil)
+		}, Options{ChunkSize: 2, Workers: 4, Writers: 3})
+		require.NoError(t, res.Err())
+		assert.Equal(t, 20, res.Count())
+		assert.LessOrEqual(t, peak, int64(3))
+	})
+	t.Run("bounds chunks waiting for writers", func(t *testing.T) {
+		var prepared int64
+		release := make(chan struct{})
+		done := make(chan result.Result)
+		go func() {
+			done <- Run(context.Background(), rangeSource(100, nil), func(items []int) (int, error) {
+				atomic.AddInt64(&prepared, 1)
+				return sum(items)
+			}, func(int) result.Result {
+				<-release
+				return result.NewResult(1, nil)
+			}, Options{ChunkSize: 1, Workers: 1, Writers: 1, Queue: 2})
+		}()
+		time.Sleep(50 * time.Millisecond)
+		// one being written, two queued and one held by the worker
+		assert.LessOrEqual(t, atomic.LoadInt64(&prepared), int64(4))
+		close(release)
+		require.Equal(t, 100, (<-done).Count())
+	})
+	t.Run("returns error of the earliest chunk", func(t *testing.T) {
+		res := Run(context.Background(), rangeSource(10, nil), sum, func(s int) result.Result {
+			switch s {
+			case 1: // first chunk
+				time.Sleep(20 * time.Millisecond)
+				return result.NewResult(0, errors.New("first"))
+			case 5:
+				return result.NewResult(0, errors.New("second"))
+			}
+			return result.NewResult(1, nil)
+		}, Options{ChunkSize: 2, Workers: 2, Writers: 4})
+		require.EqualError(t, res.Err(), "first")
+	})
+	t.Run("stops reading on failure", func(t *testing.T) {
+		var read int64
+		src := func(ctx context.Context, out chan<- int) error {
+			for i := 0; ; i++ {
+				select {
+				case out <- i:
+					atomic.AddInt64(&read, 1)
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+		res := Run(context.Background(), src, func(items []int) (int, error) {
+			return 0, errors.New("failed")
+		}, func(int) result.Result {
+			return result.NewResult(1, nil)
+		}, Options{ChunkSize: 1})
+		require.EqualError(t, res.Err(), "failed")
+		require.Zero(t, res.Count())
+	})
+	t.Run("reports source error after written chunks", func(t *testing.T) {
+		res := Run(context.Background(), rangeSource(3, errors.New("source")), sum, func(int) result.Result {
+			return result.NewResult(1, nil)
+		}, Options{ChunkSize: 2})
+		require.EqualError(t, res.Err(), "source")
+		require.Equal(t, 2, res.Count())
+	})
+}
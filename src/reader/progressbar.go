@@ -1,23 +1,67 @@
 package reader
 
 import (
-	"compress/gzip"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/schollz/progressbar/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/state303/go-discogs/src/decompress"
+	"github.com/state303/go-discogs/src/logging"
+	"github.com/state303/go-discogs/src/metrics"
 	"io"
 	"os"
 	"strings"
 	"time"
 )
 
+// NewProgressBarGzipReadCloser returns NewProgressBarReadCloser decompressing gzip with decompress.GzipStandard.
 func NewProgressBarGzipReadCloser(f *os.File, progressBarText string) (io.ReadCloser, error) {
-	reader, err := gzip.NewReader(f)
+	return NewProgressBarReadCloser(f, progressBarText, decompress.GzipStandard)
+}
+
+// NewProgressBarReadCloser returns reader decompressing f as decompress.Reader, reporting progress with a progress bar of
+// progressBarText. Progress events are logged instead if progress bars are disabled by logging.Configure.
+func NewProgressBarReadCloser(f *os.File, progressBarText string, gzipImpl string) (io.ReadCloser, error) {
+	reader, err := decompress.Reader(f, gzipImpl)
 	if err != nil {
 		return nil, err
 	}
+	closer := &multiCloser{reader, f}
 
+	filename := GetFilename(f.Name())
+	counter := &countingReader{reader, metrics.BytesDecompressed.WithLabelValues(filename)}
+	if !logging.ProgressBars() {
+		p := logging.NewProgress(logrus.Fields{logging.FieldStep: progressBarText, logging.FieldFile: filename}, -1)
+		return &readCloserImpl{io.TeeReader(counter, p.Writer()), closer, p.Finish}, nil
+	}
 	pb := getProgressBar(GetFilename(progressBarText), -1)
-	pbReader := progressbar.NewReader(reader, pb)
-	return &readCloserImpl{&pbReader, f}, nil
+	pbReader := progressbar.NewReader(counter, pb)
+	return &readCloserImpl{&pbReader, closer, func() { _ = pb.Finish() }}, nil
+}
+
+// multiCloser closes decompressor then the file it reads, returning the first error.
+type multiCloser struct {
+	decompressor io.Closer
+	file         io.Closer
+}
+
+func (c *multiCloser) Close() error {
+	err := c.decompressor.Close()
+	if fErr := c.file.Close(); err == nil {
+		err = fErr
+	}
+	return err
+}
+
+// countingReader adds bytes read from delegate into counter.
+type countingReader struct {
+	delegate io.Reader
+	counter  prometheus.Counter
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.delegate.Read(p)
+	r.counter.Add(float64(n))
+	return
 }
 
 func getProgressBar(text string, size int64) *progressbar.ProgressBar {
@@ -28,6 +72,7 @@ func getProgressBar(text string, size int64) *progressbar.ProgressBar {
 		progressbar.OptionThrottle(time.Millisecond*250),
 		progressbar.OptionSetWidth(15),
 		progressbar.OptionShowElapsedTimeOnFinish(),
+		progressbar.OptionOnCompletion(func() { _, _ = os.Stdout.WriteString("\n") }),
 		progressbar.OptionSpinnerType(70),
 		progressbar.OptionSetDescription(text),
 		progressbar.OptionSetTheme(progressbar.Theme{
@@ -48,6 +93,8 @@ func GetFilename(filepath string) string {
 type readCloserImpl struct {
 	readDelegate  io.Reader
 	closeDelegate io.Closer
+	// finish reports the end of progress, once closed.
+	finish func()
 }
 
 func (r *readCloserImpl) Read(p []byte) (n int, err error) {
@@ -55,5 +102,9 @@ func (r *readCloserImpl) Read(p []byte) (n int, err error) {
 }
 
 func (r *readCloserImpl) Close() error {
+	if r.finish != nil {
+		r.finish()
+		r.finish = nil
+	}
 	return r.closeDelegate.Close()
 }
@@ -1,8 +1,13 @@
 package reader
 
 import (
+	"bytes"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/state303/go-discogs/src/logging"
+	"github.com/state303/go-discogs/src/metrics"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io"
 	"os"
 	"path"
 	"testing"
@@ -20,11 +25,32 @@ func TestNewProgressBarGzipReadCloser(t *testing.T) {
 	r, err := NewProgressBarGzipReadCloser(f, text)
 	require.NoError(t, err)
 
+	decompressed := metrics.BytesDecompressed.WithLabelValues("data.gz")
+	before := testutil.ToFloat64(decompressed)
 	payload := make([]byte, 64)
 	n, err := r.Read(payload)
 	require.NoError(t, err)
 	require.Equal(t, n, 64)
+	require.Equal(t, before+64, testutil.ToFloat64(decompressed))
 
 	require.NoError(t, r.Close())
 	require.Error(t, f.Close())
 }
+
+func TestNewProgressBarGzipReadCloserLogsProgress(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, logging.Configure(logging.FormatJSON, buf))
+	defer func() { _ = logging.Configure(logging.FormatText, os.Stdout) }()
+
+	f, err := os.Open("testdata/data.gz")
+	require.NoError(t, err)
+	r, err := NewProgressBarGzipReadCloser(f, "updating data...")
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	require.Contains(t, buf.String(), `"msg":"progress finished"`)
+	require.Contains(t, buf.String(), `"file":"data.gz"`)
+	require.Contains(t, buf.String(), `"step":"updating data..."`)
+}
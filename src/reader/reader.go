@@ -2,11 +2,49 @@ package reader
 
 import (
 	"context"
+	"encoding/xml"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/reactivex/rxgo/v2"
+	"github.com/state303/go-discogs/src/metrics"
 	"github.com/state303/go-discogs/src/xmlparser"
 	"io"
 )
 
 func NewReader[T any](ctx context.Context, r io.ReadCloser, localName string) rxgo.Observable {
-	return xmlparser.ParseItems[T](ctx, xmlparser.SimpleTokenOrder(r, localName))
+	return xmlparser.ParseItems[T](ctx, newCountingOrder(r, localName))
+}
+
+// NewReaderWithHandler returns reader that passes elements failed to decode to onElementError.
+func NewReaderWithHandler[T any](ctx context.Context, r io.ReadCloser, localName string, onElementError xmlparser.ElementErrorHandler) rxgo.Observable {
+	return xmlparser.ParseItemsWithHandler[T](ctx, newCountingOrder(r, localName), onElementError)
+}
+
+// ReadParallel sends elements of localName read from r into out as xmlparser.ParseParallel does,
+// counting them into metrics.RecordsParsed.
+func ReadParallel[T any](ctx context.Context, r io.ReadCloser, localName string, opts xmlparser.ParallelOptions, onElementError xmlparser.ElementErrorHandler, out chan<- *T) error {
+	parsed := metrics.RecordsParsed.WithLabelValues(localName)
+	if onDecoded := opts.OnDecoded; onDecoded != nil {
+		opts.OnDecoded = func() { parsed.Inc(); onDecoded() }
+	} else {
+		opts.OnDecoded = parsed.Inc
+	}
+	return xmlparser.ParseParallel[T](ctx, r, localName, opts, onElementError, out)
+}
+
+// countingOrder counts elements decoded into metrics.RecordsParsed.
+type countingOrder struct {
+	xmlparser.TokenParseOrder
+	parsed prometheus.Counter
+}
+
+func newCountingOrder(r io.ReadCloser, localName string) xmlparser.TokenParseOrder {
+	return &countingOrder{xmlparser.SimpleTokenOrder(r, localName), metrics.RecordsParsed.WithLabelValues(localName)}
+}
+
+func (o *countingOrder) DecodeElement(v any, start *xml.StartElement) error {
+	err := o.TokenParseOrder.DecodeElement(v, start)
+	if err == nil {
+		o.parsed.Inc()
+	}
+	return err
 }
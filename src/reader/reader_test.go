@@ -3,6 +3,9 @@ package reader
 import (
 	"bytes"
 	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/state303/go-discogs/src/metrics"
+	"github.com/state303/go-discogs/src/xmlparser"
 	"github.com/stretchr/testify/require"
 	"io"
 	"os"
@@ -39,9 +42,11 @@ func TestNewReader(t *testing.T) {
 
 	t.Run("will parse items when settings are valid", func(t *testing.T) {
 		var (
-			count = 0
-			r     = getReader(t)
-			ctx   = context.Background()
+			count  = 0
+			r      = getReader(t)
+			ctx    = context.Background()
+			parsed = metrics.RecordsParsed.WithLabelValues("label")
+			before = testutil.ToFloat64(parsed)
 		)
 		observable := NewReader[XmlLabel](ctx, r, "label")
 		for item := range observable.Observe() {
@@ -52,5 +57,29 @@ func TestNewReader(t *testing.T) {
 			require.Greater(t, l.ID, int32(0))
 		}
 		require.Equal(t, 5, count)
+		require.Equal(t, before+5, testutil.ToFloat64(parsed))
+	})
+	t.Run("will parse items in parallel", func(t *testing.T) {
+		var (
+			count  = 0
+			r      = getReader(t)
+			ctx    = context.Background()
+			opts   = xmlparser.ParallelOptions{Workers: 2, Ordered: true}
+			parsed = metrics.RecordsParsed.WithLabelValues("label")
+			before = testutil.ToFloat64(parsed)
+		)
+		out := make(chan *XmlLabel)
+		done := make(chan error, 1)
+		go func() {
+			defer close(out)
+			done <- ReadParallel[XmlLabel](ctx, r, "label", opts, nil, out)
+		}()
+		for l := range out {
+			count++
+			require.Greater(t, l.ID, int32(0))
+		}
+		require.NoError(t, <-done)
+		require.Equal(t, 5, count)
+		require.Equal(t, before+5, testutil.ToFloat64(parsed))
 	})
 }
@@ -17,11 +17,15 @@ func (r *result) IsErr() bool {
 	return r.err != nil
 }
 
+// Sum adds count of that into r. The first error of either is kept.
 func (r *result) Sum(that Result) Result {
 	if that == nil {
 		return r
 	}
 	r.count += that.Count()
+	if r.err == nil {
+		r.err = that.Err()
+	}
 	return r
 }
 
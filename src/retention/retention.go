@@ -0,0 +1,136 @@
+package retention
+
+import (
+	"errors"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var dumpFilePattern = regexp.MustCompile(`^discogs_(\d{8})_(\w+)\.`)
+
+// File is a dump file found in the data directory, including CHECKSUM and partially downloaded files.
+type File struct {
+	Path        string
+	Type        string
+	GeneratedAt time.Time
+	Size        int64
+}
+
+// Scan returns every dump file directly under dir. Missing dir is regarded as empty.
+func Scan(dir string) ([]*File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []*File{}, nil
+		}
+		return nil, err
+	}
+	files := make([]*File, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := dumpFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		gen, err := time.Parse("20060102", match[1])
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &File{
+			Path:        path.Join(dir, entry.Name()),
+			Type:        strings.ToLower(match[2]),
+			GeneratedAt: gen,
+			Size:        info.Size(),
+		})
+	}
+	return files, nil
+}
+
+// checksumType is type of CHECKSUM files, which belong to the generation of dumps rather than a type of their own.
+const checksumType = "checksum"
+
+// SelectExpired returns files beyond the newest keep generations of each types.
+// CHECKSUM file is expired only along with every dump of its generation, and once newest keep generations of the
+// dir passed it, as dumps of the generation may have been purged after load. Zero or negative keep retains every file.
+func SelectExpired(files []*File, keep int) []*File {
+	if keep <= 0 {
+		return []*File{}
+	}
+	// generations of each dump types, and of every file under empty type
+	generations := make(map[string][]time.Time)
+	for _, f := range files {
+		for _, typ := range []string{f.Type, ""} {
+			if typ != checksumType && !containsTime(generations[typ], f.GeneratedAt) {
+				generations[typ] = append(generations[typ], f.GeneratedAt)
+			}
+		}
+	}
+	for _, gens := range generations {
+		sort.Slice(gens, func(i, j int) bool { return gens[i].After(gens[j]) })
+	}
+	isExpired := func(typ string, gen time.Time) bool {
+		gens := generations[typ]
+		return len(gens) > keep && !gen.After(gens[keep])
+	}
+
+	expired := make([]*File, 0)
+	for _, f := range files {
+		if f.Type != checksumType {
+			if isExpired(f.Type, f.GeneratedAt) {
+				expired = append(expired, f)
+			}
+			continue
+		}
+		kept := !isExpired("", f.GeneratedAt)
+		for typ, gens := range generations {
+			if len(typ) > 0 && containsTime(gens, f.GeneratedAt) && !isExpired(typ, f.GeneratedAt) {
+				kept = true
+			}
+		}
+		if !kept {
+			expired = append(expired, f)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].Path < expired[j].Path })
+	return expired
+}
+
+func containsTime(items []time.Time, t time.Time) bool {
+	for _, item := range items {
+		if item.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage returns total size of given files in bytes.
+func Usage(files []*File) int64 {
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	return total
+}
+
+// Remove deletes given files, then returns bytes freed. It stops at the first failure.
+func Remove(files []*File) (int64, error) {
+	var freed int64
+	for _, f := range files {
+		if err := os.Remove(f.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return freed, err
+		}
+		freed += f.Size
+	}
+	return freed, nil
+}
@@ -0,0 +1,94 @@
+package retention
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func prepareDataDir(t *testing.T) string {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"discogs_20240101_artists.xml.gz",
+		"discogs_20240201_artists.xml.gz",
+		"discogs_20240301_artists.xml.gz",
+		"discogs_20240301_artists.xml.gz.part",
+		"discogs_20240101_CHECKSUM.txt",
+		"discogs_20240301_labels.xml.gz",
+		"config.yaml",
+	} {
+		require.NoError(t, os.WriteFile(path.Join(dir, name), []byte("0123456789"), 0644))
+	}
+	require.NoError(t, os.Mkdir(path.Join(dir, "discogs_20200101_artists.xml.gz.d"), 0755))
+	return dir
+}
+
+func TestScan(t *testing.T) {
+	t.Run("scans dump files only", func(t *testing.T) {
+		files, err := Scan(prepareDataDir(t))
+		require.NoError(t, err)
+		require.Len(t, files, 6)
+		require.Equal(t, int64(60), Usage(files))
+	})
+	t.Run("missing dir is empty", func(t *testing.T) {
+		files, err := Scan(path.Join(t.TempDir(), "missing"))
+		require.NoError(t, err)
+		require.Empty(t, files)
+	})
+}
+
+func TestSelectExpired(t *testing.T) {
+	dir := prepareDataDir(t)
+	files, err := Scan(dir)
+	require.NoError(t, err)
+
+	t.Run("keeps newest generations of each types", func(t *testing.T) {
+		expired := SelectExpired(files, 2)
+		require.Len(t, expired, 2)
+		require.Equal(t, path.Join(dir, "discogs_20240101_CHECKSUM.txt"), expired[0].Path)
+		require.Equal(t, path.Join(dir, "discogs_20240101_artists.xml.gz"), expired[1].Path)
+	})
+	t.Run("part files belong to their generation", func(t *testing.T) {
+		expired := SelectExpired(files, 1)
+		require.Len(t, expired, 3)
+		for _, f := range expired {
+			require.NotContains(t, f.Path, "20240301")
+		}
+	})
+	t.Run("checksum files belong to generation of their dumps", func(t *testing.T) {
+		at := func(month time.Month) time.Time { return time.Date(2024, month, 1, 0, 0, 0, 0, time.UTC) }
+		files := []*File{
+			{Path: "01_CHECKSUM", Type: checksumType, GeneratedAt: at(1)},
+			{Path: "01_labels", Type: "labels", GeneratedAt: at(1)},
+			{Path: "02_CHECKSUM", Type: checksumType, GeneratedAt: at(2)},
+			{Path: "02_artists", Type: "artists", GeneratedAt: at(2)},
+			{Path: "03_CHECKSUM", Type: checksumType, GeneratedAt: at(3)},
+			{Path: "03_artists", Type: "artists", GeneratedAt: at(3)},
+			{Path: "04_CHECKSUM", Type: checksumType, GeneratedAt: at(4)},
+		}
+		var paths []string
+		for _, f := range SelectExpired(files, 1) {
+			paths = append(paths, f.Path)
+		}
+		// 01 is kept by labels and 03 by artists, while 04 of purged dumps is the newest generation
+		require.Equal(t, []string{"02_CHECKSUM", "02_artists"}, paths)
+	})
+	t.Run("zero keeps everything", func(t *testing.T) {
+		require.Empty(t, SelectExpired(files, 0))
+	})
+}
+
+func TestRemove(t *testing.T) {
+	dir := prepareDataDir(t)
+	files, err := Scan(dir)
+	require.NoError(t, err)
+	freed, err := Remove(SelectExpired(files, 1))
+	require.NoError(t, err)
+	require.Equal(t, int64(30), freed)
+
+	files, err = Scan(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+}
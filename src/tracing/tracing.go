@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+)
+
+const (
+	// ExporterNone disables tracing.
+	ExporterNone = "none"
+	// ExporterOTLP exports spans to an OTLP collector over http.
+	ExporterOTLP = "otlp"
+	// ExporterStderr writes spans to stderr as json, apart from logs written to stdout.
+	ExporterStderr = "stderr"
+	// ExporterFile writes spans to a file as json lines, for offline use.
+	ExporterFile = "file"
+)
+
+const (
+	instrumentation = "github.com/state303/go-discogs"
+	serviceName     = "go-discogs"
+)
+
+// Attribute keys of spans.
+const (
+	KeyType  = attribute.Key("discogs.type")
+	KeyTable = attribute.Key("discogs.table")
+	KeyChunk = attribute.Key("discogs.chunk")
+	KeyCount = attribute.Key("discogs.count")
+)
+
+// Options configures the exporter of Setup.
+type Options struct {
+	// Exporter is one of ExporterNone, ExporterOTLP, ExporterStderr or ExporterFile. Empty means ExporterNone.
+	Exporter string
+	// Endpoint is url of the OTLP collector, such as http://localhost:4318.
+	// Empty uses OTEL_EXPORTER_OTLP_ENDPOINT or the default of the exporter.
+	Endpoint string
+	// File is path of the file to write spans into for ExporterFile.
+	File string
+	// Version of the tool, recorded as service version.
+	Version string
+}
+
+// ValidExporter returns error if exporter is unknown.
+func ValidExporter(exporter string) error {
+	switch exporter {
+	case "", ExporterNone, ExporterOTLP, ExporterStderr, ExporterFile:
+		return nil
+	}
+	return fmt.Errorf("unknown trace exporter: %+v", exporter)
+}
+
+// Setup registers global tracer provider exporting spans as configured. Returned func flushes pending spans
+// then shuts the provider down. Nothing is exported with ExporterNone.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	var (
+		exporter sdktrace.SpanExporter
+		closer   func() error
+		err      error
+	)
+	switch opts.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var o []otlptracehttp.Option
+		if len(opts.Endpoint) > 0 {
+			o = append(o, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, o...)
+	case ExporterStderr:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	case ExporterFile:
+		var f *os.File
+		if f, err = os.OpenFile(opts.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644); err != nil {
+			return nil, err
+		}
+		closer = f.Close
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		return nil, ValidExporter(opts.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName), semconv.ServiceVersion(opts.Version))
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if closeErr := closer(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span of name as a child of the span in ctx, if any.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records count and error status into span, then ends it.
+func End(span trace.Span, count int, err error) {
+	span.SetAttributes(KeyCount.Int(count))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Detach returns context carrying only the span of ctx, so that work traced under it, such as writes of a chunk
+// in flight, is not aborted by cancellation of ctx.
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
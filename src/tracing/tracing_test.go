@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// record registers a tracer provider recording spans in memory for the test.
+func record(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return exporter
+}
+
+func TestEnd(t *testing.T) {
+	exporter := record(t)
+
+	_, span := Start(context.Background(), "ok", KeyTable.String("artist"))
+	End(span, 3, nil)
+	_, span = Start(context.Background(), "failed")
+	End(span, 1, errors.New("rejected"))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Contains(t, spans[0].Attributes, KeyTable.String("artist"))
+	assert.Contains(t, spans[0].Attributes, KeyCount.Int(3))
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Contains(t, spans[1].Attributes, KeyCount.Int(1))
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, "rejected", spans[1].Status.Description)
+	require.Len(t, spans[1].Events, 1)
+}
+
+func TestDetach(t *testing.T) {
+	exporter := record(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, parent := Start(ctx, "parent")
+	cancel()
+	detached := Detach(ctx)
+	require.NoError(t, detached.Err())
+	_, child := Start(detached, "child")
+	child.End()
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+}
+
+func TestSetup(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	t.Run("none exports nothing", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Options{Exporter: ExporterNone})
+		require.NoError(t, err)
+		require.NoError(t, shutdown(context.Background()))
+	})
+	t.Run("file appends spans as json", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "spans.jsonl")
+		shutdown, err := Setup(context.Background(), Options{Exporter: ExporterFile, File: path, Version: "v1.0.0"})
+		require.NoError(t, err)
+		_, span := Start(context.Background(), "step", KeyType.String("artists"))
+		End(span, 10, nil)
+		require.NoError(t, shutdown(context.Background()))
+
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(b), `"Name":"step"`)
+		assert.Contains(t, string(b), "discogs.type")
+		assert.Contains(t, string(b), "go-discogs")
+	})
+	t.Run("unknown exporter", func(t *testing.T) {
+		_, err := Setup(context.Background(), Options{Exporter: "zipkin"})
+		require.ErrorContains(t, err, "unknown trace exporter")
+		require.Error(t, ValidExporter("stdout"), "spans must not mix with logs on stdout")
+	})
+}
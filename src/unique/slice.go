@@ -1,18 +1,27 @@
 package unique
 
-import "github.com/mitchellh/hashstructure/v2"
+import "github.com/state303/go-discogs/model"
 
-func Slice[T comparable](items []T) []T {
-	m := make(map[uint64]struct{})
-	r := make([]T, 0)
-	for i := range items {
-		hash, err := hashstructure.Hash(items[i], hashstructure.FormatV2, nil)
-		if err != nil {
-			panic(err)
-		}
-		if _, ok := m[hash]; !ok {
-			m[hash] = struct{}{}
-			r = append(r, items[i])
+// Slice returns items of distinct Key, keeping the first of each in order. Rows of the same primary key are
+// duplicates even if other columns differ, as they would conflict on write.
+func Slice[T model.Keyer](items []T) []T {
+	return SliceBy(items, func(item T) model.Key { return item.Key() })
+}
+
+// Values returns distinct items, keeping the first of each in order.
+func Values[T comparable](items []T) []T {
+	return SliceBy(items, func(item T) T { return item })
+}
+
+// SliceBy returns items of distinct key, keeping the first of each in order.
+func SliceBy[T any, K comparable](items []T, key func(item T) K) []T {
+	seen := make(map[K]struct{}, len(items))
+	r := make([]T, 0, len(items))
+	for _, item := range items {
+		k := key(item)
+		if _, ok := seen[k]; !ok {
+			seen[k] = struct{}{}
+			r = append(r, item)
 		}
 	}
 	return r
@@ -1,7 +1,9 @@
 package unique
 
 import (
+	"fmt"
 	"github.com/state303/go-discogs/model"
+	"github.com/state303/go-discogs/src/helper"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -24,3 +26,54 @@ func Test_getUniqueSlice(t *testing.T) {
 	)
 	assert.Len(t, result, 2)
 }
+
+func TestSliceByPrimaryKey(t *testing.T) {
+	first, second := "A1", "B1"
+	tracks := []*model.ReleaseTrack{
+		{ReleaseID: 1, TitleHash: 10, Position: &first},
+		{ReleaseID: 1, TitleHash: 10, Position: &second},
+		{ReleaseID: 2, TitleHash: 10, Position: &second},
+	}
+	result := Slice(tracks)
+	assert.Equal(t, []*model.ReleaseTrack{tracks[0], tracks[2]}, result, "rows of the same key must keep the first")
+
+	genres := Slice([]*model.Genre{{Name: "Rock"}, {Name: "Jazz"}, {Name: "Rock"}})
+	assert.Len(t, genres, 2, "genres are keyed by name, as ids are assigned by the database")
+	assert.Empty(t, Slice([]*model.Genre{}))
+}
+
+func TestValues(t *testing.T) {
+	assert.Equal(t, []string{"Techno", "House"}, Values([]string{"Techno", "House", "Techno"}))
+	assert.Empty(t, Values[string](nil))
+}
+
+func TestSliceBy(t *testing.T) {
+	words := []string{"a", "bb", "cc", "d"}
+	assert.Equal(t, []string{"a", "bb"}, SliceBy(words, func(w string) int { return len(w) }))
+}
+
+// BenchmarkSlice dedupes slices shaped as those of a release, of which a few rows are duplicates.
+func BenchmarkSlice(b *testing.B) {
+	const size = 12
+	var (
+		tracks  = make([]*model.ReleaseTrack, 0, size)
+		credits = make([]*model.ReleaseCreditedArtist, 0, size)
+	)
+	for i := 0; i < size; i++ {
+		title, duration, role := fmt.Sprintf("Track %d", i%10), "4:20", "Producer"
+		tracks = append(tracks, &model.ReleaseTrack{ReleaseID: 1, Title: &title, Duration: &duration, TitleHash: int64(helper.Fnv32Str(title))})
+		credits = append(credits, &model.ReleaseCreditedArtist{ReleaseID: 1, ArtistID: int32(i % 10), Role: &role, RoleHash: int64(helper.Fnv32Str(role))})
+	}
+	b.Run("tracks", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Slice(tracks)
+		}
+	})
+	b.Run("credits", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Slice(credits)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package xmlparser
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ElementError is returned when a matched element fails to decode. Following elements can still be parsed.
+type ElementError struct {
+	// Name is local name of the element.
+	Name string
+	// ID is either id attribute of the element, or ID field decoded before the failure. Empty if unknown.
+	ID  string
+	Err error
+	// Raw is raw bytes of the element, or nil if unknown.
+	Raw []byte
+}
+
+func (e *ElementError) Error() string {
+	return fmt.Sprintf("failed to decode <%+v> (id: %+v): %+v", e.Name, e.ID, e.Err)
+}
+
+func (e *ElementError) Unwrap() error {
+	return e.Err
+}
+
+// ElementErrorHandler decides whether to continue on ElementError. Returning nil skips the element,
+// while returning err stops parsing with the err.
+type ElementErrorHandler func(err *ElementError) error
+
+func newElementError(v any, se xml.StartElement, err error) *ElementError {
+	e := &ElementError{Name: se.Name.Local, ID: getSourceID(v, se), Err: err}
+	var rawErr *rawError
+	if errors.As(err, &rawErr) {
+		e.Err, e.Raw = rawErr.err, rawErr.raw
+	}
+	return e
+}
+
+func getSourceID(v any, se xml.StartElement) string {
+	for _, attr := range se.Attr {
+		if attr.Name.Local == "id" {
+			return attr.Value
+		}
+	}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return ""
+	}
+	if f := rv.FieldByName("ID"); f.IsValid() && !f.IsZero() {
+		return fmt.Sprint(f.Interface())
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package xmlparser
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// ParallelOptions configures ParseParallel. Zero values are replaced by defaults.
+type ParallelOptions struct {
+	// Workers is the number of goroutines decoding elements. Defaults to runtime.NumCPU.
+	Workers int
+	// Ordered emits elements in order of the source, at the cost of holding those decoded early.
+	Ordered bool
+	// OnDecoded is called on each element decoded, if not nil. It is called concurrently by workers.
+	OnDecoded func()
+}
+
+func (o ParallelOptions) withDefaults() ParallelOptions {
+	if o.Workers < 1 {
+		o.Workers = runtime.NumCPU()
+	}
+	return o
+}
+
+// element is either raw bytes of an element read, or a value decoded from it, along with its sequence in the source.
+type element[T any] struct {
+	seq int
+	raw []byte
+	v   *T
+	err error
+}
+
+// ParseParallel sends elements of localName read from r into out, decoding them on Workers goroutines.
+// A single goroutine splits r into raw bytes of each element with Splitter, which are then unmarshalled in parallel.
+// Elements are sent as soon as decoded, unless Ordered. Elements failed to decode are passed to onElementError as
+// ParseItemsWithHandler does.
+// Returns the error that stopped parsing, or error of ctx once it is done. r is closed once reading stops, while out
+// is left open.
+func ParseParallel[T any](ctx context.Context, r io.ReadCloser, localName string, opts ParallelOptions, onElementError ElementErrorHandler, out chan<- *T) error {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		raws    = make(chan element[T], opts.Workers)
+		decoded = make(chan element[T], opts.Workers)
+	)
+
+	go func() {
+		defer close(raws)
+		defer func() { _ = r.Close() }()
+		splitter := NewSplitter(r, localName)
+		for seq := 0; ; seq++ {
+			raw, err := splitter.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			select {
+			case raws <- element[T]{seq: seq, raw: raw, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	workers := new(sync.WaitGroup)
+	for i := 0; i < opts.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for e := range raws {
+				if e.err == nil {
+					e.v, e.err = decodeRaw[T](e.raw)
+					e.raw = nil
+				}
+				if e.err == nil && opts.OnDecoded != nil {
+					opts.OnDecoded()
+				}
+				select {
+				case decoded <- e:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(decoded)
+	}()
+
+	emit := func(e element[T]) error {
+		if e.err != nil {
+			var elemErr *ElementError
+			if onElementError == nil || !errors.As(e.err, &elemErr) {
+				return e.err
+			}
+			return onElementError(elemErr)
+		}
+		select {
+		case out <- e.v:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	var (
+		next    = 0
+		pending = make(map[int]element[T])
+	)
+	for e := range decoded {
+		if !opts.Ordered {
+			if err := emit(e); err != nil {
+				return err
+			}
+			continue
+		}
+		pending[e.seq] = e
+		for e, ok := pending[next]; ok; e, ok = pending[next] {
+			delete(pending, next)
+			next++
+			if err := emit(e); err != nil {
+				return err
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// decodeRaw unmarshals raw bytes of an element into T, returning ElementError on failure.
+func decodeRaw[T any](raw []byte) (*T, error) {
+	v := new(T)
+	if err := xml.Unmarshal(raw, v); err != nil {
+		elemErr := newElementError(v, startElementOf(raw), err)
+		elemErr.Raw = raw
+		return nil, elemErr
+	}
+	return v, nil
+}
+
+// startElementOf returns the start element of raw bytes of an element, or zero value if none is read.
+func startElementOf(raw []byte) xml.StartElement {
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.StartElement{}
+		}
+		if se, ok := token.(xml.StartElement); ok {
+			return se
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package xmlparser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+// parseAll runs ParseParallel until it returns, collecting every element sent.
+func parseAll[T any](ctx context.Context, r io.ReadCloser, localName string, opts ParallelOptions, onElementError ElementErrorHandler) ([]*T, error) {
+	out := make(chan *T)
+	done := make(chan error, 1)
+	go func() {
+		defer close(out)
+		done <- ParseParallel[T](ctx, r, localName, opts, onElementError, out)
+	}()
+	items := make([]*T, 0)
+	for v := range out {
+		items = append(items, v)
+	}
+	return items, <-done
+}
+
+func TestParseParallel(t *testing.T) {
+	t.Run("reads the same items as ParseItems in order", func(t *testing.T) {
+		f, err := os.Open("testdata/data.xml")
+		require.NoError(t, err)
+		expected := make([]*Data, 0)
+		for item := range ParseItems[Data](context.Background(), SimpleTokenOrder(f, "Contents")).Observe() {
+			require.NoError(t, item.E)
+			expected = append(expected, item.V.(*Data))
+		}
+
+		f, err = os.Open("testdata/data.xml")
+		require.NoError(t, err)
+		opts := ParallelOptions{Workers: 4, Ordered: true}
+		actual, err := parseAll[Data](context.Background(), f, "Contents", opts, nil)
+		require.NoError(t, err)
+		require.Len(t, actual, 777)
+		require.Equal(t, expected, actual)
+	})
+	t.Run("reads every item unordered", func(t *testing.T) {
+		f, err := os.Open("testdata/data.xml")
+		require.NoError(t, err)
+		items, err := parseAll[Data](context.Background(), f, "Contents", ParallelOptions{}, nil)
+		require.NoError(t, err)
+		require.Len(t, items, 777)
+	})
+	t.Run("stops on cancellation", func(t *testing.T) {
+		f, err := os.Open("testdata/data.xml")
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		out := make(chan *Data)
+		done := make(chan error, 1)
+		go func() { done <- ParseParallel[Data](ctx, f, "Contents", ParallelOptions{Workers: 2}, nil, out) }()
+		for count := 0; count < 10; count++ {
+			<-out
+		}
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	})
+	t.Run("calls OnDecoded on each element", func(t *testing.T) {
+		f, err := os.Open("testdata/data.xml")
+		require.NoError(t, err)
+		var decoded int32
+		opts := ParallelOptions{Workers: 2, OnDecoded: func() { atomic.AddInt32(&decoded, 1) }}
+		_, err = parseAll[Data](context.Background(), f, "Contents", opts, nil)
+		require.NoError(t, err)
+		require.Equal(t, int32(777), decoded)
+	})
+	t.Run("fails on malformed source", func(t *testing.T) {
+		src := io.NopCloser(bytes.NewBufferString(`<items><item><id>1</id></item><item><id>2`))
+		_, err := parseAll[Data](context.Background(), src, "item", ParallelOptions{}, nil)
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+}
+
+func TestParseParallelWithHandler(t *testing.T) {
+	type Item struct {
+		ID   int32  `xml:"id"`
+		Name string `xml:"name"`
+		Rank int32  `xml:"rank"`
+	}
+	const src = `<items>
+<item><id>1</id><name>a</name><rank>1</rank></item>
+<item><id>2</id><name>b</name><rank>high</rank><item><id>9</id></item></item>
+<item><id>3</id><name>c</name><rank>3</rank></item>
+</items>`
+	parse := func(onElementError ElementErrorHandler) ([]*Item, error) {
+		r := io.NopCloser(bytes.NewBufferString(src))
+		opts := ParallelOptions{Workers: 2, Ordered: true}
+		return parseAll[Item](context.Background(), r, "item", opts, onElementError)
+	}
+
+	t.Run("skips element when handler returns nil", func(t *testing.T) {
+		handled := make([]*ElementError, 0)
+		items, err := parse(func(err *ElementError) error {
+			handled = append(handled, err)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, int32(3), items[1].ID)
+		require.Len(t, handled, 1)
+		require.Equal(t, "item", handled[0].Name)
+		require.Equal(t, "2", handled[0].ID)
+		require.Equal(t, "<item><id>2</id><name>b</name><rank>high</rank><item><id>9</id></item></item>", string(handled[0].Raw))
+	})
+	t.Run("stops with err of handler", func(t *testing.T) {
+		items, err := parse(func(err *ElementError) error { return fmt.Errorf("stopped: %w", err) })
+		require.ErrorContains(t, err, "stopped")
+		require.Len(t, items, 1)
+	})
+	t.Run("stops on element error without handler", func(t *testing.T) {
+		_, err := parse(nil)
+		var elemErr *ElementError
+		require.ErrorAs(t, err, &elemErr)
+	})
+}
@@ -29,22 +29,34 @@ type TokenParseOrder interface {
 }
 
 // SimpleTokenOrder returns TokenParseOrder implementation that matches specific localName of the token from XML stream.
+// Elements failed to decode are read through their end, and their raw bytes are carried on ElementError.
 func SimpleTokenOrder(source io.ReadCloser, localName string) TokenParseOrder {
-	return &closeDecoderWrapper{xml.NewDecoder(source), source, NewStartTokenNameMatcher(localName)}
+	rec := &recorder{r: source}
+	return &closeDecoderWrapper{decoder: xml.NewDecoder(rec), closer: source, matcher: NewStartTokenNameMatcher(localName), rec: rec}
 }
 
 type closeDecoderWrapper struct {
-	decoder Decoder
+	decoder *xml.Decoder
 	closer  io.Closer
 	matcher TokenMatcher
+	rec     *recorder
+	// start is offset of the token last read
+	start int64
 }
 
 func (c *closeDecoderWrapper) Token() (xml.Token, error) {
+	c.start = c.decoder.InputOffset()
+	c.rec.discard(c.start)
 	return c.decoder.Token()
 }
 
 func (c *closeDecoderWrapper) DecodeElement(v any, start *xml.StartElement) error {
-	return c.decoder.DecodeElement(v, start)
+	err := c.decoder.DecodeElement(v, start)
+	if err == nil {
+		return nil
+	}
+	skipRest(c.decoder, c.rec.slice(c.start, c.decoder.InputOffset()), err)
+	return &rawError{err: err, raw: c.rec.slice(c.start, c.decoder.InputOffset())}
 }
 
 func (c *closeDecoderWrapper) Close() error {
@@ -80,7 +92,10 @@ func (p *parserImpl[T]) Parse(ctx context.Context, order TokenParseOrder) rxgo.O
 				token, err := order.Token()
 				if err != nil {
 					if !errors.Is(err, io.EOF) {
-						c <- rxgo.Error(err)
+						select {
+						case c <- rxgo.Error(err):
+						case <-ctx.Done():
+						}
 					}
 					return
 				}
@@ -89,10 +104,14 @@ func (p *parserImpl[T]) Parse(ctx context.Context, order TokenParseOrder) rxgo.O
 				}
 				se := token.(xml.StartElement)
 				v := new(T)
+				item := rxgo.Of(v)
 				if err := order.DecodeElement(v, &se); err != nil {
-					c <- rxgo.Error(err)
-				} else {
-					c <- rxgo.Of(v)
+					item = rxgo.Error(newElementError(v, se, err))
+				}
+				select {
+				case c <- item:
+				case <-ctx.Done():
+					return
 				}
 			}
 		}
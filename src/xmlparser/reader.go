@@ -2,16 +2,25 @@ package xmlparser
 
 import (
 	"context"
+	"errors"
 	"github.com/reactivex/rxgo/v2"
 )
 
 // ParseItems reads annotated item of types T with context.Context.
 // TokenParseOrder will dictate current io.Reader position and io.Closer for resource
 func ParseItems[T any](ctx context.Context, order TokenParseOrder) rxgo.Observable {
+	return ParseItemsWithHandler[T](ctx, order, nil)
+}
+
+// ParseItemsWithHandler reads items as ParseItems, but elements failed to decode are passed to onElementError,
+// which decides to skip them or to stop. Nil onElementError stops on any error.
+func ParseItemsWithHandler[T any](ctx context.Context, order TokenParseOrder, onElementError ElementErrorHandler) rxgo.Observable {
 	c := make(chan rxgo.Item)
 	go func(c chan rxgo.Item) {
+		// order is closed by the parser, which stops once cancelled here
+		ctx, cancel := context.WithCancel(ctx)
 		parser := NewParser[T]()
-		defer func() { close(c); _ = order.Close() }()
+		defer func() { close(c); cancel() }()
 		in := parser.Parse(ctx, order).Observe()
 		for {
 			select {
@@ -22,6 +31,14 @@ func ParseItems[T any](ctx context.Context, order TokenParseOrder) rxgo.Observab
 					return
 				}
 				if item.Error() {
+					var elemErr *ElementError
+					if onElementError != nil && errors.As(item.E, &elemErr) {
+						if err := onElementError(elemErr); err == nil {
+							continue
+						} else {
+							item.E = err
+						}
+					}
 					c <- rxgo.Error(item.E)
 					return
 				} else {
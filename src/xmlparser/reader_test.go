@@ -3,6 +3,7 @@ package xmlparser
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"io"
@@ -102,3 +103,52 @@ func TestParseItems(t *testing.T) {
 		}
 	})
 }
+
+func TestParseItemsWithHandler(t *testing.T) {
+	type Item struct {
+		ID   int32  `xml:"id"`
+		Name string `xml:"name"`
+		Rank int32  `xml:"rank"`
+	}
+	const src = `<items>
+<item><id>1</id><name>a</name><rank>1</rank></item>
+<item><id>2</id><name>b</name><rank>high</rank><item><id>9</id></item></item>
+<item><id>3</id><name>c</name><rank>3</rank></item>
+</items>`
+	parse := func(onElementError ElementErrorHandler) ([]*Item, error) {
+		order := SimpleTokenOrder(io.NopCloser(bytes.NewBufferString(src)), "item")
+		items := make([]*Item, 0)
+		for item := range ParseItemsWithHandler[Item](context.Background(), order, onElementError).Observe() {
+			if item.E != nil {
+				return items, item.E
+			}
+			items = append(items, item.V.(*Item))
+		}
+		return items, nil
+	}
+
+	t.Run("skips element when handler returns nil", func(t *testing.T) {
+		handled := make([]*ElementError, 0)
+		items, err := parse(func(err *ElementError) error {
+			handled = append(handled, err)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, int32(3), items[1].ID)
+		require.Len(t, handled, 1)
+		require.Equal(t, "item", handled[0].Name)
+		require.Equal(t, "2", handled[0].ID)
+		require.Equal(t, "<item><id>2</id><name>b</name><rank>high</rank><item><id>9</id></item></item>", string(handled[0].Raw))
+	})
+	t.Run("stops with err of handler", func(t *testing.T) {
+		items, err := parse(func(err *ElementError) error { return fmt.Errorf("stopped: %w", err) })
+		require.ErrorContains(t, err, "stopped")
+		require.Len(t, items, 1)
+	})
+	t.Run("stops on element error without handler", func(t *testing.T) {
+		_, err := parse(nil)
+		var elemErr *ElementError
+		require.ErrorAs(t, err, &elemErr)
+	})
+}
@@ -0,0 +1,94 @@
+package xmlparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// discardThreshold is bytes read before the current token that recorder keeps, before discarding them at once.
+const discardThreshold = 64 * 1024
+
+// recorder keeps bytes read from r since offset base, so that raw bytes of an element can be sliced by offsets of
+// xml.Decoder reading from it.
+type recorder struct {
+	r    io.Reader
+	buf  []byte
+	base int64
+}
+
+func (rec *recorder) Read(p []byte) (int, error) {
+	n, err := rec.r.Read(p)
+	rec.buf = append(rec.buf, p[:n]...)
+	return n, err
+}
+
+// discard drops bytes before offset, once they pile up over discardThreshold.
+func (rec *recorder) discard(offset int64) {
+	if n := offset - rec.base; n > discardThreshold {
+		rec.buf = append(rec.buf[:0], rec.buf[n:]...)
+		rec.base = offset
+	}
+}
+
+// slice returns a copy of bytes from offset from to offset to.
+func (rec *recorder) slice(from, to int64) []byte {
+	if from < rec.base || to-rec.base > int64(len(rec.buf)) || from > to {
+		return nil
+	}
+	return append([]byte(nil), rec.buf[from-rec.base:to-rec.base]...)
+}
+
+// rawError is an error of decoding an element, along with raw bytes of the element.
+type rawError struct {
+	err error
+	raw []byte
+}
+
+func (e *rawError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rawError) Unwrap() error {
+	return e.err
+}
+
+// depthOf returns depth of elements left open at the end of raw.
+func depthOf(raw []byte) int {
+	depth := 0
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return depth
+		}
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// skipRest reads tokens of decoder through the end of the element of which raw bytes are read so far,
+// unless it failed by malformed XML.
+func skipRest(decoder *xml.Decoder, raw []byte, err error) {
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return
+	}
+	for depth := depthOf(raw); depth > 0; {
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
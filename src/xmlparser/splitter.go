@@ -0,0 +1,179 @@
+package xmlparser
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Splitter splits an XML stream into raw bytes of each outermost element of a local name, such as every
+// <release ...>...</release> of a dump, without decoding them. Elements of the same name nested in a matched element,
+// such as <label> of <sublabels>, are part of the matched one. Comments and CDATA sections are skipped over.
+type Splitter struct {
+	r    *bufio.Reader
+	name []byte
+	buf  []byte
+}
+
+// NewSplitter returns Splitter of elements of localName read from r.
+func NewSplitter(r io.Reader, localName string) *Splitter {
+	return &Splitter{r: bufio.NewReaderSize(r, 64*1024), name: []byte(localName)}
+}
+
+// Next returns raw bytes of the next element, or io.EOF once the stream ends. Returned bytes are owned by the caller.
+func (s *Splitter) Next() ([]byte, error) {
+	s.buf = nil
+	depth := 0
+	for {
+		// skip to the next markup, keeping text only inside an element
+		text, err := s.r.ReadSlice('<')
+		for errors.Is(err, bufio.ErrBufferFull) {
+			if depth > 0 {
+				s.buf = append(s.buf, text...)
+			}
+			text, err = s.r.ReadSlice('<')
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) && depth > 0 {
+				return nil, fmt.Errorf("unexpected EOF in <%s>: %w", s.name, io.ErrUnexpectedEOF)
+			}
+			return nil, err
+		}
+		if depth > 0 {
+			s.buf = append(s.buf, text...)
+		}
+
+		kind, err := s.peekMarkup()
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case markupComment:
+			err = s.skipThrough(depth > 0, []byte("-->"))
+		case markupCData:
+			err = s.skipThrough(depth > 0, []byte("]]>"))
+		case markupStart:
+			if depth == 0 {
+				s.buf = append(s.buf, '<')
+			}
+			var selfClosing bool
+			if selfClosing, err = s.readTag(); err == nil && !selfClosing {
+				depth++
+			} else if err == nil && depth == 0 {
+				return s.buf, nil
+			}
+		case markupEnd:
+			if depth == 0 {
+				continue // stray end tag outside of an element
+			}
+			if _, err = s.readTag(); err == nil {
+				if depth--; depth == 0 {
+					return s.buf, nil
+				}
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+type markup int
+
+const (
+	markupOther markup = iota
+	markupStart
+	markupEnd
+	markupComment
+	markupCData
+)
+
+// peekMarkup tells kind of markup following '<' just read, without consuming it.
+func (s *Splitter) peekMarkup() (markup, error) {
+	n := len(s.name) + 2 // slash, name and a delimiter
+	if n < len("![CDATA[") {
+		n = len("![CDATA[")
+	}
+	b, err := s.r.Peek(n)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return markupOther, err
+	}
+	switch {
+	case bytes.HasPrefix(b, []byte("!--")):
+		return markupComment, nil
+	case bytes.HasPrefix(b, []byte("![CDATA[")):
+		return markupCData, nil
+	case s.isName(b):
+		return markupStart, nil
+	case len(b) > 0 && b[0] == '/' && s.isName(b[1:]):
+		return markupEnd, nil
+	}
+	return markupOther, nil
+}
+
+// isName tells whether b starts with the name followed by a delimiter of a tag.
+func (s *Splitter) isName(b []byte) bool {
+	if !bytes.HasPrefix(b, s.name) || len(b) <= len(s.name) {
+		return false
+	}
+	switch b[len(s.name)] {
+	case ' ', '\t', '\r', '\n', '>', '/':
+		return true
+	}
+	return false
+}
+
+// readTag reads the rest of a tag through '>', skipping '>' in quoted attribute values. It tells whether the tag
+// is self-closing.
+func (s *Splitter) readTag() (bool, error) {
+	var (
+		quote byte
+		prev  byte
+	)
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return false, err
+		}
+		s.buf = append(s.buf, c)
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return prev == '/', nil
+		}
+		prev = c
+	}
+}
+
+// skipThrough reads through end, keeping what is read only if keep.
+func (s *Splitter) skipThrough(keep bool, end []byte) error {
+	tail := make([]byte, 0, len(end))
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if keep {
+			s.buf = append(s.buf, c)
+		}
+		if len(tail) == len(end) {
+			tail = append(tail[:0], tail[1:]...)
+		}
+		if tail = append(tail, c); bytes.Equal(tail, end) {
+			return nil
+		}
+	}
+}
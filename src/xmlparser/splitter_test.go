@@ -0,0 +1,69 @@
+package xmlparser
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSplitter(t *testing.T) {
+	split := func(src, localName string) ([]string, error) {
+		s := NewSplitter(strings.NewReader(src), localName)
+		elements := make([]string, 0)
+		for {
+			b, err := s.Next()
+			if errors.Is(err, io.EOF) {
+				return elements, nil
+			} else if err != nil {
+				return elements, err
+			}
+			elements = append(elements, string(b))
+		}
+	}
+
+	t.Run("splits elements of name only", func(t *testing.T) {
+		elements, err := split(`<?xml version="1.0"?><releases><release id="1"><title>a</title></release>
+<release	id="2"><title>b</title></release><releasex>c</releasex></releases>`, "release")
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`<release id="1"><title>a</title></release>`,
+			`<release	id="2"><title>b</title></release>`,
+		}, elements)
+	})
+	t.Run("keeps nested elements of the same name", func(t *testing.T) {
+		elements, err := split(`<labels><label><id>1</id><sublabels><label id="2">b</label></sublabels></label></labels>`, "label")
+		require.NoError(t, err)
+		require.Equal(t, []string{`<label><id>1</id><sublabels><label id="2">b</label></sublabels></label>`}, elements)
+	})
+	t.Run("splits self-closing elements", func(t *testing.T) {
+		elements, err := split(`<labels><label id="1"/><label id="2" /></labels>`, "label")
+		require.NoError(t, err)
+		require.Equal(t, []string{`<label id="1"/>`, `<label id="2" />`}, elements)
+	})
+	t.Run("skips markup in attributes, comments and CDATA", func(t *testing.T) {
+		elements, err := split(`<!-- <label> --><label name="a>b"><![CDATA[</label>]]><!-- </label> --></label>`, "label")
+		require.NoError(t, err)
+		require.Equal(t, []string{`<label name="a>b"><![CDATA[</label>]]><!-- </label> --></label>`}, elements)
+	})
+	t.Run("reads elements larger than the buffer", func(t *testing.T) {
+		text := strings.Repeat("x", 200*1024)
+		elements, err := split(`<artists><artist><profile>`+text+`</profile></artist></artists>`, "artist")
+		require.NoError(t, err)
+		require.Equal(t, []string{`<artist><profile>` + text + `</profile></artist>`}, elements)
+	})
+	t.Run("fails on unterminated element", func(t *testing.T) {
+		elements, err := split(`<artists><artist><id>1</id></artist><artist><id>2</id>`, "artist")
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		require.Len(t, elements, 1)
+	})
+	t.Run("splits every element the parser decodes", func(t *testing.T) {
+		src, err := os.ReadFile("testdata/data.xml")
+		require.NoError(t, err)
+		elements, err := split(string(src), "Contents")
+		require.NoError(t, err)
+		require.Len(t, elements, 777)
+	})
+}